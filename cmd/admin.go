@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/auth"
+)
+
+// Pool statistics for a database - used to size pools from real data
+func (app *application) databaseStatsHandler(w http.ResponseWriter, r *http.Request) {
+	type PoolSettings struct {
+		MaxOpenConns    int    `json:"max_open_conns"`
+		MaxIdleConns    int    `json:"max_idle_conns"`
+		ConnMaxLifetime string `json:"conn_max_lifetime"`
+		ConnMaxIdleTime string `json:"conn_max_idle_time"`
+	}
+
+	type PoolStats struct {
+		MaxOpenConnections int    `json:"max_open_connections"`
+		OpenConnections    int    `json:"open_connections"`
+		InUse              int    `json:"in_use"`
+		Idle               int    `json:"idle"`
+		WaitCount          int64  `json:"wait_count"`
+		WaitDuration       string `json:"wait_duration"`
+		WaitDurationMs     int64  `json:"wait_duration_ms"`
+		MaxIdleClosed      int64  `json:"max_idle_closed"`
+		MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+		MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+	}
+
+	type AdmissionStats struct {
+		MaxInFlight int    `json:"max_in_flight"`
+		MaxQueue    int    `json:"max_queue"`
+		MaxWait     string `json:"max_wait"`
+		InFlight    int    `json:"in_flight"`
+		Queued      int    `json:"queued"`
+		Admitted    uint64 `json:"admitted"`
+		Rejected    uint64 `json:"rejected"`
+		TimedOut    uint64 `json:"timed_out"`
+		Waited      string `json:"waited"`
+	}
+
+	dbID := strings.ToLower(chi.URLParam(r, "database_id"))
+
+	dbConfig, exists := app.databaseConfig(dbID)
+	if !exists {
+		writeDatabaseNotFound(w)
+		return
+	}
+
+	app.dbMutex.RLock()
+	db, connected := app.dbs[dbID]
+	app.dbMutex.RUnlock()
+
+	if !connected {
+		apierror.Write(w, http.StatusServiceUnavailable,
+			"Database Unavailable",
+			"The database is configured but not currently connected.",
+			"DB_UNAVAILABLE")
+		return
+	}
+
+	stats := db.Stats()
+	admitted := app.admissionController(dbID).Stats()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"database_id": dbID,
+		"pool": PoolSettings{
+			MaxOpenConns:    dbConfig.Pool.MaxOpenConns,
+			MaxIdleConns:    dbConfig.Pool.IdleConns(),
+			ConnMaxLifetime: dbConfig.Pool.ConnMaxLifetime.String(),
+			ConnMaxIdleTime: dbConfig.Pool.ConnMaxIdleTime.String(),
+		},
+		"stats": PoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDuration:       stats.WaitDuration.String(),
+			WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+			MaxIdleClosed:      stats.MaxIdleClosed,
+			MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+		},
+		"admission": AdmissionStats{
+			MaxInFlight: admitted.MaxInFlight,
+			MaxQueue:    admitted.MaxQueue,
+			MaxWait:     admitted.MaxWait.String(),
+			InFlight:    admitted.InFlight,
+			Queued:      admitted.Queued,
+			Admitted:    admitted.Admitted,
+			Rejected:    admitted.Rejected,
+			TimedOut:    admitted.TimedOut,
+			Waited:      admitted.Waited.String(),
+		},
+	})
+}
+
+// List API keys (never includes hashes or plaintext)
+func (app *application) listKeysHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.requireKeyStore(w) {
+		return
+	}
+
+	keys, err := app.keyStore.List(r.Context())
+	if err != nil {
+		slog.Error("failed to list api keys", "error", err)
+		apierror.Write(w, http.StatusInternalServerError,
+			"Internal Server Error", "Failed to list API keys", "INTERNAL_ERROR")
+		return
+	}
+	if keys == nil {
+		keys = []auth.APIKey{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys":  keys,
+		"count": len(keys),
+	})
+}
+
+// Mint a new API key. The plaintext key is returned only in this response.
+func (app *application) mintKeyHandler(w http.ResponseWriter, r *http.Request) {
+	type MintKeyRequest struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		Roles  []string `json:"roles"`
+	}
+
+	if !app.requireKeyStore(w) {
+		return
+	}
+
+	var req MintKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest,
+			"Invalid Request", "Unable to parse request body.", "INVALID_JSON")
+		return
+	}
+	if req.Name == "" || len(req.Scopes) == 0 {
+		apierror.Write(w, http.StatusBadRequest,
+			"Validation Error", "name and at least one scope are required.", "VALIDATION_ERROR")
+		return
+	}
+
+	scopes, err := auth.ParseScopes(req.Scopes)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "Validation Error", err.Error(), "INVALID_SCOPE")
+		return
+	}
+	for _, scope := range scopes {
+		if scope.Database == "" || scope.Database == "*" {
+			continue
+		}
+		if _, exists := app.databaseConfig(scope.Database); !exists {
+			apierror.Write(w, http.StatusBadRequest, "Validation Error",
+				fmt.Sprintf("Scope %q refers to an unknown database.", scope), "INVALID_SCOPE")
+			return
+		}
+	}
+
+	for _, role := range req.Roles {
+		dbID, name, qualified := strings.Cut(strings.ToLower(role), ":")
+		if !qualified {
+			dbID, name = "*", dbID
+		}
+		_, known := app.databaseConfig(dbID)
+		if (dbID != "*" && !known) || !app.policy.HasRole(name) {
+			apierror.Write(w, http.StatusBadRequest, "Validation Error",
+				fmt.Sprintf("Role %q refers to an unknown database or role.", role), "INVALID_ROLE")
+			return
+		}
+	}
+
+	key, plaintext, err := auth.MintKey(r.Context(), app.keyStore, req.Name, req.Scopes, req.Roles...)
+	if err != nil {
+		slog.Error("failed to mint api key", "error", err)
+		apierror.Write(w, http.StatusInternalServerError,
+			"Internal Server Error", "Failed to mint API key", "INTERNAL_ERROR")
+		return
+	}
+
+	slog.Info("api key minted",
+		"key_id", key.ID,
+		"name", key.Name,
+		"scopes", key.Scopes,
+		"roles", key.Roles)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":         key.ID,
+		"name":       key.Name,
+		"scopes":     key.Scopes,
+		"roles":      key.Roles,
+		"created_at": key.CreatedAt,
+		"key":        plaintext,
+	})
+}
+
+// Revoke an API key. Revoked keys stay listed for auditing.
+func (app *application) revokeKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.requireKeyStore(w) {
+		return
+	}
+
+	keyID := chi.URLParam(r, "key_id")
+	err := app.keyStore.Revoke(r.Context(), keyID)
+	if errors.Is(err, auth.ErrKeyNotFound) {
+		apierror.Write(w, http.StatusNotFound,
+			"Not Found", "The specified API key does not exist.", "KEY_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		slog.Error("failed to revoke api key", "key_id", keyID, "error", err)
+		apierror.Write(w, http.StatusInternalServerError,
+			"Internal Server Error", "Failed to revoke API key", "INTERNAL_ERROR")
+		return
+	}
+
+	slog.Info("api key revoked", "key_id", keyID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) requireKeyStore(w http.ResponseWriter) bool {
+	if app.keyStore == nil {
+		apierror.Write(w, http.StatusNotFound,
+			"Not Found", "API key authentication is not configured.", "API_KEYS_DISABLED")
+		return false
+	}
+	return true
+}
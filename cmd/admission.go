@@ -0,0 +1,106 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/admission"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+func admissionLimits(a config.AdmissionConfig) admission.Limits {
+	return admission.Limits{
+		MaxInFlight: a.MaxInFlight,
+		MaxQueue:    a.MaxQueue,
+		MaxWait:     a.MaxWait,
+	}
+}
+
+// admissionController returns the admission controller of a database,
+// creating it from the configuration on first use.
+func (app *application) admissionController(dbID string) *admission.Controller {
+	app.admissionMutex.Lock()
+	defer app.admissionMutex.Unlock()
+
+	controller, exists := app.admission[dbID]
+	if !exists {
+		dbConfig, _ := app.databaseConfig(dbID)
+		controller = admission.NewController(admissionLimits(dbConfig.Admission))
+		app.admission[dbID] = controller
+	}
+	return controller
+}
+
+// admissionStats returns the statistics of every admission controller, by
+// database id.
+func (app *application) admissionStats() map[string]admission.Stats {
+	app.admissionMutex.Lock()
+	defer app.admissionMutex.Unlock()
+	stats := make(map[string]admission.Stats, len(app.admission))
+	for dbID, controller := range app.admission {
+		stats[dbID] = controller.Stats()
+	}
+	return stats
+}
+
+// retuneAdmission applies reloaded admission settings to a live controller.
+func (app *application) retuneAdmission(dbID string, a config.AdmissionConfig) {
+	app.admissionMutex.Lock()
+	defer app.admissionMutex.Unlock()
+	if controller, exists := app.admission[dbID]; exists {
+		controller.SetLimits(admissionLimits(a))
+	}
+}
+
+// removeAdmission drops the controller of a removed database.
+func (app *application) removeAdmission(dbID string) {
+	app.admissionMutex.Lock()
+	defer app.admissionMutex.Unlock()
+	delete(app.admission, dbID)
+}
+
+// admissionMiddleware bounds the requests working against each database, so
+// that a burst is queued briefly or shed with a 503 instead of piling up
+// inside the connection pool until the request timeout fires. It runs before
+// the pool is touched, so the id comes from the (resolved) URL.
+func (app *application) admissionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dbID := strings.ToLower(chi.URLParam(r, "database_id"))
+		if !app.isConfiguredDatabase(dbID) {
+			// Rejected downstream; no controller for made-up names
+			next.ServeHTTP(w, r)
+			return
+		}
+		controller := app.admissionController(dbID)
+
+		release, err := controller.Acquire(r.Context())
+		if err != nil {
+			if r.Context().Err() != nil {
+				// Client gone or request timed out; nobody to answer
+				return
+			}
+			stats := controller.Stats()
+			slog.Warn("request rejected by admission control",
+				"database_id", dbID,
+				"reason", err,
+				"in_flight", stats.InFlight,
+				"queued", stats.Queued)
+
+			retryAfter := int(math.Ceil(stats.MaxWait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			apierror.Write(w, http.StatusServiceUnavailable,
+				"Database Busy",
+				"The database is handling too many requests. Please retry shortly.",
+				"DB_OVERLOADED")
+			return
+		}
+		defer release()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+func TestAdmissionMiddleware_ShedsExcessLoad(t *testing.T) {
+	captureLogs(t, nil)
+	app := newTestApp(map[string]config.DatabaseConfig{
+		"sales": {
+			Host: "127.0.0.1", Port: 1, SID: "SALES", User: "u", Password: "p",
+			Admission: config.AdmissionConfig{MaxInFlight: 1, MaxQueue: 1, MaxWait: 2 * time.Second},
+		},
+	})
+
+	// A handler that holds its slot until released
+	entered := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+	router := chi.NewRouter()
+	router.With(app.admissionMiddleware).Get("/api/{database_id}/crypto/transactions", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		return serve(router, "GET", "/api/sales/crypto/transactions", "", nil)
+	}
+
+	results := make(chan int, 2)
+	go func() { results <- request().Code }()
+	<-entered
+	go func() { results <- request().Code }()
+
+	controller := app.admissionController("sales")
+	for controller.Stats().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// One running, one queued: the third is rejected straight away
+	w := request()
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After 2, got %q", got)
+	}
+
+	close(unblock)
+	for i := 0; i < 2; i++ {
+		if code := <-results; code != http.StatusOK {
+			t.Errorf("expected admitted requests to succeed, got %d", code)
+		}
+	}
+	if stats := controller.Stats(); stats.Admitted != 2 || stats.Rejected != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestAdmissionMiddleware_RunsBeforeThePool(t *testing.T) {
+	captureLogs(t, nil)
+	sales := sqliteDatabase(t, "sales")
+	sales.Admission = config.AdmissionConfig{MaxInFlight: 1, MaxQueue: 0, MaxWait: 10 * time.Millisecond}
+	app := newTestApp(map[string]config.DatabaseConfig{"sales": sales})
+
+	// Arrange: the only slot is taken
+	release, err := app.admissionController("sales").Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	defer release()
+
+	// Act
+	w := serve(app.mount(), "GET", "/api/sales/crypto/transactions", "", nil)
+
+	// Assert: shed without connecting to the database
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body)
+	}
+	if _, connected := app.connectedDBs()["sales"]; connected {
+		t.Error("expected a shed request not to open the pool")
+	}
+}
+
+func TestGetOrConnectDB_KeepsExhaustedPool(t *testing.T) {
+	captureLogs(t, nil)
+	sales := sqliteDatabase(t, "sales")
+	sales.Pool = config.PoolConfig{MaxOpenConns: 1, MaxIdleConns: config.IdleConns(1)}
+	app := newTestApp(map[string]config.DatabaseConfig{"sales": sales})
+	db, err := app.connectDatabase("sales")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	// Arrange: hold the only connection
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("failed to take a connection: %v", err)
+	}
+
+	// Act
+	got, err := app.getOrConnectDB(context.Background(), "sales")
+
+	// Assert: the busy pool is handed out, not closed and redialled
+	if err != nil {
+		t.Fatalf("expected the busy pool, got %v", err)
+	}
+	if got != db {
+		t.Error("expected the existing pool to be kept")
+	}
+	conn.Close()
+	if err := db.Ping(); err != nil {
+		t.Errorf("expected the pool to stay open, got %v", err)
+	}
+}
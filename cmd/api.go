@@ -2,16 +2,20 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
+	"net"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/auth"
+	"github.com/hotbrandon/go-chi/internal/config"
 	"github.com/hotbrandon/go-chi/internal/handlers"
-	"github.com/hotbrandon/go-chi/internal/repo"
+	"github.com/hotbrandon/go-chi/internal/logging"
+	"github.com/hotbrandon/go-chi/internal/ratelimit"
+	"github.com/hotbrandon/go-chi/internal/tracing"
 )
 
 func (app *application) mount() http.Handler {
@@ -20,29 +24,109 @@ func (app *application) mount() http.Handler {
 	// Global middleware
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(tracing.Middleware)
+	r.Use(app.metrics.Middleware(app.isConfiguredDatabase))
+	r.Use(logging.Middleware(app.accessLogOptions))
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(middleware.Timeout(app.cfg.Server.RequestTimeout))
 
 	// Health checks (app-specific, stay as methods)
 	r.Get("/health", app.healthCheckHandler)
+	r.Get("/health/liveness", app.healthCheckHandler)
+	r.Get("/health/startup", app.startupCheckHandler)
 	r.Get("/health/readiness", app.readinessCheckHandler)
 	r.Get("/databases", app.listDatabasesHandler)
 
+	// Prometheus scrape endpoint
+	r.Handle("/metrics", app.metrics.Handler())
+
+	// Operational endpoints
+	r.Route("/admin", func(r chi.Router) {
+		if app.authEnabled() {
+			r.Use(auth.Middleware(app.authenticators...))
+			r.Use(auth.RequireScope(auth.AdminScope))
+		}
+		r.Use(ratelimit.Middleware(app.rateLimits, "admin", app.rateLimitRules("admin")))
+
+		r.Get("/databases/{database_id}/stats", app.databaseStatsHandler)
+		r.Get("/databases/{database_id}/queries", app.databaseQueriesHandler)
+		r.Get("/databases/{database_id}/mode", app.databaseModeHandler)
+		r.Put("/databases/{database_id}/mode", app.setDatabaseModeHandler)
+		r.Delete("/databases/{database_id}/mode", app.clearDatabaseModeHandler)
+
+		r.Get("/keys", app.listKeysHandler)
+		r.Post("/keys", app.mintKeyHandler)
+		r.Delete("/keys/{key_id}", app.revokeKeyHandler)
+	})
+
 	// Initialize domain handlers
-	cryptoHandlers := handlers.NewCryptoHandlers()
+	cryptoHandlers := handlers.NewCryptoHandlers(app.metrics)
+
+	// Fan-out routes, querying every database the caller may read. Static
+	// segments win over {database_id}, which may therefore not be "_all".
+	// The {database_id} here is "_all" or a group, so the per-database
+	// limits are applied by fanOut to each database instead.
+	fanOutRoutes := func(r chi.Router) {
+		r.Use(ratelimit.Middleware(app.rateLimits, "api", func() ratelimit.Rules {
+			return ratelimit.Rules{PerClient: app.rateLimitRules("api")().PerClient}
+		}))
+
+		r.Get("/crypto/transactions", app.allTransactionsHandler)
+		r.Get("/crypto/holdings", app.allHoldingsHandler)
+	}
+	r.Route("/api/"+config.AllDatabases, func(r chi.Router) {
+		if app.authEnabled() {
+			r.Use(auth.Middleware(app.authenticators...))
+		}
+		fanOutRoutes(r)
+	})
+
+	// Groups answer the same routes, over their members only
+	groups := chi.NewRouter()
+	groups.NotFound(writeGroupUnsupported)
+	groups.MethodNotAllowed(writeGroupUnsupported)
+	fanOutRoutes(groups)
 
 	// API routes
 	r.Route("/api/{database_id}", func(r chi.Router) {
+		// Authorize before touching the database, so callers without
+		// access cannot trigger connection attempts. Aliases and groups are
+		// resolved first, as access is granted per database.
+		if app.authEnabled() {
+			r.Use(auth.Middleware(app.authenticators...))
+			r.Use(app.resolveDatabase(groups))
+			r.Use(auth.RequireDatabaseAccess)
+			r.Use(auth.Authorize(app.policy, "/api/{database_id}"))
+		} else {
+			r.Use(app.resolveDatabase(groups))
+		}
+		// Limit after authentication, so that clients are keyed by identity
+		// rather than IP, and before a connection is taken from the pool.
+		// Admission comes before the pool is pinged, for the same reason.
+		r.Use(ratelimit.Middleware(app.rateLimits, "api", app.rateLimitRules("api")))
+		r.Use(app.admissionMiddleware)
 		r.Use(app.databaseMiddleware)
 
 		// Crypto endpoints
 		r.Route("/crypto", func(r chi.Router) {
 			r.Get("/transactions", cryptoHandlers.ListTransactions)
-			r.Post("/transactions", cryptoHandlers.CreateTransaction)
 			r.Get("/transactions/{id}", cryptoHandlers.GetTransaction)
+			r.Get("/transactions/{id}/history", cryptoHandlers.TransactionHistory)
+			r.Get("/holdings", cryptoHandlers.Holdings)
+
+			// Writes are all or nothing
+			r.Group(func(r chi.Router) {
+				r.Use(app.unitOfWork)
+				r.Post("/transactions", cryptoHandlers.CreateTransaction)
+				r.Post("/transactions/import", cryptoHandlers.ImportTransactions)
+				r.Put("/transactions/{id}", cryptoHandlers.UpdateTransaction)
+				r.Delete("/transactions/{id}", cryptoHandlers.DeleteTransaction)
+			})
 		})
 
+		// Audit trail of the writes above
+		r.Get("/audit", cryptoHandlers.ListAudit)
+
 		// Future: Add more domains as needed
 		// usersHandlers := handlers.NewUsersHandlers(slog.Default())
 		// r.Route("/users", func(r chi.Router) {
@@ -54,118 +138,27 @@ func (app *application) mount() http.Handler {
 	return r
 }
 
-func (app *application) serve() error {
+// serve listens on the server address and serves the API in the
+// background, reporting why it stopped on the returned channel.
+func (app *application) serve() (*http.Server, <-chan error, error) {
 	srv := &http.Server{
-		Addr:         app.cfg.appAddr,
+		Addr:         app.cfg.Server.Addr,
 		Handler:      app.mount(),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-
-	slog.Info("server starting",
-		"address", app.cfg.appAddr,
-		"databases", len(app.dbs))
-	return srv.ListenAndServe()
-}
-
-// Health checks remain as application methods (they're infrastructure concerns)
-func (app *application) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":    "ok",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
-}
-
-// Readiness check - tests all database connections
-func (app *application) readinessCheckHandler(w http.ResponseWriter, r *http.Request) {
-	type DatabaseHealth struct {
-		ID        string `json:"id"`
-		Available bool   `json:"available"`
-		Latency   string `json:"latency,omitempty"`
-		Error     string `json:"error,omitempty"`
-	}
-
-	type ReadinessResponse struct {
-		Status     string           `json:"status"` // "ready", "degraded", "not_ready"
-		Timestamp  string           `json:"timestamp"`
-		Databases  []DatabaseHealth `json:"databases"`
-		TotalDBs   int              `json:"total_databases"`
-		HealthyDBs int              `json:"healthy_databases"`
+		ReadTimeout:  app.cfg.Server.ReadTimeout,
+		WriteTimeout: app.cfg.Server.WriteTimeout,
+		IdleTimeout:  app.cfg.Server.IdleTimeout,
 	}
-
-	health := ReadinessResponse{
-		Status:    "ready",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		TotalDBs:  len(app.dbs),
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	healthyCount := 0
-
-	for dbID, db := range app.dbs {
-		start := time.Now()
-		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
-		err := db.PingContext(ctx)
-		cancel()
-		latency := time.Since(start)
-
-		dbHealth := DatabaseHealth{
-			ID:        dbID,
-			Available: err == nil,
-		}
-
-		if err == nil {
-			dbHealth.Latency = latency.String()
-			healthyCount++
-		} else {
-			dbHealth.Error = err.Error()
-		}
-
-		health.Databases = append(health.Databases, dbHealth)
-	}
-
-	health.HealthyDBs = healthyCount
-
-	// Determine overall status
-	if healthyCount == 0 {
-		health.Status = "not_ready"
-	} else if healthyCount < len(app.dbs) {
-		health.Status = "degraded"
-	}
-
-	statusCode := http.StatusOK
-	if health.Status == "not_ready" {
-		statusCode = http.StatusServiceUnavailable
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(health)
-}
-
-// List available databases - useful for frontend to build UI
-func (app *application) listDatabasesHandler(w http.ResponseWriter, r *http.Request) {
-	type DatabaseInfo struct {
-		ID        string `json:"id"`
-		Available bool   `json:"available"`
-	}
-
-	databases := make([]DatabaseInfo, 0, len(app.dbs))
-	for dbID := range app.dbs {
-		databases = append(databases, DatabaseInfo{
-			ID:        dbID,
-			Available: true,
-		})
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"databases": databases,
-		"count":     len(databases),
-	})
+	slog.Info("server starting",
+		"address", app.cfg.Server.Addr,
+		"databases", len(app.cfg.Databases))
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(listener) }()
+	return srv, served, nil
 }
 
 // Middleware injects repository instead of raw DB
@@ -173,38 +166,62 @@ func (app *application) databaseMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		dbID := strings.ToLower(chi.URLParam(r, "database_id"))
 
-		if _, exists := app.cfg.databases[dbID]; !exists {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   "Database Not Found",
-				"message": "The specified database does not exist or is not configured",
-				"code":    "DB_NOT_FOUND",
-			})
+		dbConfig, exists := app.databaseConfig(dbID)
+		if !exists {
+			writeDatabaseNotFound(w)
+			return
+		}
+
+		// Checked before connecting, as the database may be down for the
+		// window
+		if mode, _ := app.databaseMode(dbID, dbConfig); !modeAllows(mode, r.Method) {
+			writeModeUnavailable(w, mode)
 			return
 		}
 
-		db, err := app.getOrConnectDB(dbID)
+		db, err := app.getOrConnectDB(r.Context(), dbID)
 		if err != nil {
-			slog.Warn("database unavailable during request",
+			logging.FromContext(r.Context()).Warn("database unavailable during request",
 				"database_id", dbID,
 				"error", err)
 
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusServiceUnavailable)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   "Database Unavailable",
-				"message": "The database is temporarily unavailable. Please try again later.",
-				"code":    "DB_UNAVAILABLE",
-			})
+			apierror.Write(w, http.StatusServiceUnavailable,
+				"Database Unavailable",
+				"The database is temporarily unavailable. Please try again later.",
+				"DB_UNAVAILABLE")
 			return
 		}
 
 		// Inject repository (not raw DB)
-		repository := repo.New(db)
+		repository, err := app.repository(r.Context(), dbID, db, dbConfig, replicaReads(r))
+		if err != nil {
+			logging.FromContext(r.Context()).Warn("no read replica available during request",
+				"database_id", dbID,
+				"error", err)
+
+			apierror.Write(w, http.StatusServiceUnavailable,
+				"Replica Unavailable",
+				"No read replica is available. Retry, or send X-Read-Consistency: strong to read from the primary.",
+				"REPLICA_UNAVAILABLE")
+			return
+		}
 		ctx := context.WithValue(r.Context(), handlers.RepoContextKey, repository)
 		ctx = context.WithValue(ctx, handlers.DBIDContextKey, dbID)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// isConfiguredDatabase reports whether dbID, as given in a URL, names a
+// configured database.
+func (app *application) isConfiguredDatabase(dbID string) bool {
+	_, exists := app.databaseConfig(dbID)
+	return exists
+}
+
+func writeDatabaseNotFound(w http.ResponseWriter) {
+	apierror.Write(w, http.StatusNotFound,
+		"Database Not Found",
+		"The specified database does not exist or is not configured",
+		"DB_NOT_FOUND")
+}
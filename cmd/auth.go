@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/hotbrandon/go-chi/internal/auth"
+)
+
+// setupAuth builds the request authenticators from the auth configuration.
+// With none configured, the API stays open.
+func (app *application) setupAuth() error {
+	keys := app.cfg.Auth.APIKeys
+
+	switch keys.Store {
+	case "file":
+		store, err := auth.NewFileKeyStore(keys.File)
+		if err != nil {
+			return fmt.Errorf("open api key file: %w", err)
+		}
+		app.keyStore = store
+	case "oracle":
+		dbID := keys.DatabaseID
+		app.keyStore = auth.NewSQLKeyStore(func() (*sql.DB, error) {
+			return app.getOrConnectDB(context.Background(), dbID)
+		})
+	}
+
+	if app.keyStore != nil {
+		app.authenticators = append(app.authenticators, auth.APIKeyAuthenticator{Store: app.keyStore})
+	}
+
+	// API keys are tried first; they only claim X-API-Key and gck_ bearer
+	// tokens, leaving every other bearer token to the JWT authenticator
+	jwtConfig := app.cfg.Auth.JWT
+	if jwtConfig.Enabled() {
+		app.authenticators = append(app.authenticators, &auth.JWTAuthenticator{
+			Keys:           auth.NewJWKS(jwtConfig.JWKSURL, nil, jwtConfig.JWKSCacheTTL),
+			Issuer:         jwtConfig.Issuer,
+			Audience:       jwtConfig.Audience,
+			DatabasesClaim: jwtConfig.DatabasesClaim,
+			GroupsClaim:    jwtConfig.GroupsClaim,
+			GroupScopes:    jwtConfig.GroupScopes,
+			RolesClaim:     jwtConfig.RolesClaim,
+		})
+	}
+
+	if len(app.authenticators) == 0 {
+		slog.Warn("authentication is not configured, the API is open to anyone who can reach it")
+		return nil
+	}
+
+	policyConfig := app.cfg.Auth.Policy
+	policy, err := auth.NewPolicy(auth.PolicyRules{
+		Roles:      policyConfig.Roles,
+		Databases:  policyConfig.Databases,
+		ScopeRoles: policyConfig.ScopeRoles,
+	})
+	if err != nil {
+		return fmt.Errorf("auth policy: %w", err)
+	}
+	app.policy = policy
+
+	slog.Info("authentication configured",
+		"api_key_store", keys.Store,
+		"jwks_url", jwtConfig.JWKSURL)
+	return nil
+}
+
+// authEnabled reports whether requests must be authenticated.
+func (app *application) authEnabled() bool {
+	return len(app.authenticators) > 0
+}
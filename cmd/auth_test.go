@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/auth"
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+// newAuthTestApp returns an app using a file key store with one admin key
+func newAuthTestApp(t *testing.T) (*application, string) {
+	t.Helper()
+
+	app := newTestApp(map[string]config.DatabaseConfig{
+		"sales":   {Host: "127.0.0.1", Port: 1, SID: "SALES", User: "u", Password: "p"},
+		"finance": {Host: "127.0.0.1", Port: 1, SID: "FIN", User: "u", Password: "p"},
+	})
+	app.cfg.Auth.APIKeys = config.APIKeysConfig{
+		Store: "file",
+		File:  filepath.Join(t.TempDir(), "keys.yaml"),
+	}
+	if err := app.setupAuth(); err != nil {
+		t.Fatalf("setupAuth failed: %v", err)
+	}
+
+	_, adminKey, err := auth.MintKey(context.Background(), app.keyStore, "bootstrap", []string{"admin"})
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+	return app, adminKey
+}
+
+func serve(router http.Handler, method, path, key string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeys_MintUseRevoke(t *testing.T) {
+	captureLogs(t, nil)
+	app, adminKey := newAuthTestApp(t)
+	router := app.mount()
+
+	// Admin endpoints require the admin scope
+	if w := serve(router, "GET", "/admin/keys", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a key, got %d", w.Code)
+	}
+
+	// Mint a read-only key for sales
+	w := serve(router, "POST", "/admin/keys", adminKey, map[string]interface{}{
+		"name":   "reporting",
+		"scopes": []string{"sales:read"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var minted struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	json.NewDecoder(w.Body).Decode(&minted)
+
+	// The new key cannot use admin endpoints
+	if w := serve(router, "GET", "/admin/keys", minted.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a non-admin key, got %d", w.Code)
+	}
+
+	// Allowed requests pass auth and reach the (unreachable) database
+	if w := serve(router, "GET", "/api/sales/crypto/transactions", minted.Key, nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected auth to pass through to the database (503), got %d", w.Code)
+	}
+	if w := serve(router, "POST", "/api/sales/crypto/transactions", minted.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a write with a read scope, got %d", w.Code)
+	}
+	if w := serve(router, "GET", "/api/finance/crypto/transactions", minted.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for another database, got %d", w.Code)
+	}
+
+	// Revoke it
+	if w := serve(router, "DELETE", "/admin/keys/"+minted.ID, adminKey, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w := serve(router, "GET", "/api/sales/crypto/transactions", minted.Key, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a revoked key, got %d", w.Code)
+	}
+}
+
+func TestAPIKeys_MintValidation(t *testing.T) {
+	captureLogs(t, nil)
+	app, adminKey := newAuthTestApp(t)
+	router := app.mount()
+
+	tests := []struct {
+		name   string
+		scopes []string
+		roles  []string
+	}{
+		{"unknown database", []string{"marketing:read"}, nil},
+		{"invalid access", []string{"sales:delete"}, nil},
+		{"no scopes", nil, nil},
+		{"unknown role", []string{"sales:write"}, []string{"superuser"}},
+		{"role on unknown database", []string{"sales:write"}, []string{"marketing:admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, "POST", "/admin/keys", adminKey, map[string]interface{}{
+				"name":   "x",
+				"scopes": tt.scopes,
+				"roles":  tt.roles,
+			})
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+	"github.com/hotbrandon/go-chi/internal/redact"
+	"github.com/hotbrandon/go-chi/internal/secrets"
+)
+
+// command is a subcommand of the binary. Names of more than one word
+// ("config check") are matched word by word.
+type command struct {
+	name    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var commands = []command{
+	{"serve", "run the HTTP API (the default)", runServe},
+	{"config check", "validate the configuration and print the resolved databases", runConfigCheck},
+	{"db ping", "connect to each database and report latency", runDBPing},
+	{"migrate", "apply, revert or list schema migrations", runMigrate},
+	{"import", "load transactions from CSV into a database", runImport},
+	{"export", "write the transactions of a database as CSV", runExport},
+}
+
+// findCommand picks the subcommand named by args and returns the remaining
+// arguments. Without a command, or when args start with a flag, it serves,
+// so that `api -config file.yaml` keeps working.
+func findCommand(args []string) (command, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commands[0], args, nil
+	}
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], nil
+		}
+	}
+	return command{}, nil, fmt.Errorf("unknown command %q", strings.Join(args, " "))
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: api [command] [flags]")
+	fmt.Fprintln(w)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run `api <command> -h` for the flags of a command.")
+}
+
+// cli holds what every subcommand shares: the log redactor, which output
+// should also pass through, and the standard streams.
+type cli struct {
+	redactor *redact.Redactor
+	in       io.Reader
+	out      io.Writer
+}
+
+// flagSet returns the flags of a subcommand, with the -config flag every
+// subcommand takes.
+func (c *cli) flagSet(name, usage string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: api %s [flags]\n\n%s", name, usage)
+		flags.PrintDefaults()
+	}
+	configPath := flags.String("config", os.Getenv("CONFIG_FILE"), "path to YAML config file")
+	return flags, configPath
+}
+
+// loadConfig loads the configuration the way the server does and registers
+// its credentials with the redactor.
+func (c *cli) loadConfig(path string) (*config.Config, *secrets.Resolver, error) {
+	// Secret providers are configured from the environment only, since the
+	// config file itself may reference them
+	secretResolver, err := secrets.FromEnv(os.Environ())
+	if err != nil {
+		return nil, nil, fmt.Errorf("configure secret providers: %w", err)
+	}
+	cfg, _, err := loadConfig(path, secretResolver)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load configuration: %w", err)
+	}
+	c.redactor.AddSecrets(cfg.SecretValues()...)
+	return cfg, secretResolver, nil
+}
+
+// selectDatabases parses a comma-separated -databases flag, or returns
+// defaults when it is empty.
+func selectDatabases(cfg *config.Config, list string, defaults []string) ([]string, error) {
+	if list == "" {
+		if len(defaults) == 0 {
+			return nil, errors.New("no database configurations found")
+		}
+		return defaults, nil
+	}
+	var ids []string
+	for _, id := range strings.Split(list, ",") {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if _, exists := cfg.Databases[id]; !exists {
+			return nil, fmt.Errorf("database %q is not configured", id)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// openCLIDatabase connects to dbID with a pool sized for one command
+// rather than the server's.
+func openCLIDatabase(cfg *config.Config, dbID string) (*sql.DB, error) {
+	dbConfig := cfg.Databases[dbID]
+	return openDatabase(dialect(dbConfig).DriverName(), dbConfig.BuildDSN(), dbID, config.PoolConfig{MaxOpenConns: 2, MaxIdleConns: config.IdleConns(1)})
+}
+
+// runConfigCheck loads the configuration as the server would and prints
+// the databases it resolved. Databases skipped as incomplete fail the
+// check.
+func runConfigCheck(c *cli, args []string) error {
+	flags, configPath := c.flagSet("config check", "")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	secretResolver, err := secrets.FromEnv(os.Environ())
+	if err != nil {
+		return fmt.Errorf("configure secret providers: %w", err)
+	}
+	cfg, warnings, err := loadConfig(*configPath, secretResolver)
+	if err != nil {
+		return fmt.Errorf("load configuration: %w", err)
+	}
+	c.redactor.AddSecrets(cfg.SecretValues()...)
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DATABASE\tDSN\tMAX OPEN\tMAX IDLE\tMAX IN FLIGHT")
+	for _, dbID := range cfg.DatabaseIDs() {
+		db := cfg.Databases[dbID]
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", dbID, c.redactor.String(db.BuildDSN().String()),
+			db.Pool.MaxOpenConns, db.Pool.IdleConns(), db.Admission.MaxInFlight)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(c.out, "skipped: %s\n", c.redactor.String(warning.Error()))
+	}
+	if cfg.Server.Addr == "" {
+		return errors.New("server address is not set (APP_ADDR or server.addr)")
+	}
+	if len(warnings) > 0 {
+		return fmt.Errorf("%d database configuration(s) are incomplete", len(warnings))
+	}
+	if len(cfg.Databases) == 0 {
+		return errors.New("no database configurations found")
+	}
+	return nil
+}
+
+// runDBPing connects to each selected database and reports how long the
+// connection and a round trip took. Every database is tried; the command
+// fails if any is unreachable.
+func runDBPing(c *cli, args []string) error {
+	flags, configPath := c.flagSet("db ping", "")
+	databases := flags.String("databases", "", "comma-separated database ids (default: all)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, _, err := c.loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	ids, err := selectDatabases(cfg, *databases, cfg.DatabaseIDs())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DATABASE\tSTATUS\tCONNECT\tPING\tERROR")
+	for _, dbID := range ids {
+		start := time.Now()
+		db, err := openCLIDatabase(cfg, dbID)
+		connect := time.Since(start)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\tdown\t%s\t-\t%s\n", dbID, connect.Round(time.Millisecond), c.redactor.String(err.Error()))
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		start = time.Now()
+		err = db.PingContext(ctx)
+		ping := time.Since(start)
+		cancel()
+		db.Close()
+
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\tdown\t%s\t%s\t%s\n", dbID, connect.Round(time.Millisecond),
+				ping.Round(time.Millisecond), c.redactor.String(err.Error()))
+			continue
+		}
+		fmt.Fprintf(w, "%s\tup\t%s\t%s\t\n", dbID, connect.Round(time.Millisecond), ping.Round(time.Millisecond))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d databases unreachable", failed, len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+// Connection states of a database, as /databases lists them
+const (
+	stateConnected    = "connected"
+	stateReconnecting = "reconnecting" // dialled by the next request
+	stateCircuitOpen  = "circuit_open" // failed; not dialled before next_retry_at
+	stateDisabled     = "disabled"     // in maintenance, not dialled at all
+)
+
+// pingRecord holds the outcome of the last checks of a database
+type pingRecord struct {
+	lastOK      time.Time
+	lastError   string // Redacted
+	lastErrorAt time.Time
+}
+
+// recordPing notes the outcome of a ping or connection attempt to dbID.
+func (app *application) recordPing(dbID string, err error) {
+	app.pingsMutex.Lock()
+	defer app.pingsMutex.Unlock()
+	record := app.pings[dbID]
+	if err == nil {
+		record.lastOK = time.Now()
+	} else {
+		record.lastError = app.redactor.String(err.Error())
+		record.lastErrorAt = time.Now()
+	}
+	app.pings[dbID] = record
+}
+
+// removePings drops the ping history of a removed database.
+func (app *application) removePings(dbID string) {
+	app.pingsMutex.Lock()
+	defer app.pingsMutex.Unlock()
+	delete(app.pings, dbID)
+}
+
+// List every configured database and how it is doing - useful for frontend
+// to build UI, and for operators. ?probe=true pings (or dials) each one
+// first, instead of reporting the last checks.
+func (app *application) listDatabasesHandler(w http.ResponseWriter, r *http.Request) {
+	type PoolStats struct {
+		MaxOpenConnections int    `json:"max_open_connections"`
+		OpenConnections    int    `json:"open_connections"`
+		InUse              int    `json:"in_use"`
+		Idle               int    `json:"idle"`
+		WaitCount          int64  `json:"wait_count"`
+		WaitDuration       string `json:"wait_duration"`
+	}
+
+	type DatabaseInfo struct {
+		ID            string     `json:"id"`
+		State         string     `json:"state"`
+		Available     bool       `json:"available"`
+		Mode          string     `json:"mode"`
+		ModeUntil     *time.Time `json:"mode_until,omitempty"`
+		Driver        string     `json:"driver"`
+		Host          string     `json:"host,omitempty"`
+		Service       string     `json:"service,omitempty"`
+		ServerVersion string     `json:"server_version,omitempty"`
+		LastPingAt    *time.Time `json:"last_ping_at,omitempty"`
+		LastError     string     `json:"last_error,omitempty"`
+		LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+		NextRetryAt   *time.Time `json:"next_retry_at,omitempty"`
+		Pool          *PoolStats `json:"pool,omitempty"`
+	}
+
+	app.cfgMutex.RLock()
+	configs := make(map[string]config.DatabaseConfig, len(app.cfg.Databases))
+	for id, dbConfig := range app.cfg.Databases {
+		configs[id] = dbConfig
+	}
+	ids := app.cfg.DatabaseIDs()
+	aliases := make(map[string]string, len(app.cfg.Aliases))
+	for name, id := range app.cfg.Aliases {
+		aliases[name] = id
+	}
+	groups := make(map[string][]string, len(app.cfg.Groups))
+	for name, ids := range app.cfg.Groups {
+		groups[name] = ids
+	}
+	app.cfgMutex.RUnlock()
+
+	if probe, _ := strconv.ParseBool(r.URL.Query().Get("probe")); probe {
+		var wg sync.WaitGroup
+		for _, dbID := range ids {
+			if mode, _ := app.databaseMode(dbID, configs[dbID]); mode.Name == config.ModeMaintenance {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Failures are recorded for the listing below
+				app.getOrConnectDB(r.Context(), dbID)
+			}()
+		}
+		wg.Wait()
+	}
+
+	dbs := app.connectedDBs()
+	databases := make([]DatabaseInfo, 0, len(ids))
+	for _, dbID := range ids {
+		dbConfig := configs[dbID]
+		mode, _ := app.databaseMode(dbID, dbConfig)
+		host, service := dbConfig.Endpoint()
+		info := DatabaseInfo{
+			ID:      dbID,
+			Mode:    mode.Name,
+			Driver:  dbConfig.Driver,
+			Host:    app.redactor.String(host),
+			Service: app.redactor.String(service),
+		}
+		if !mode.Until.IsZero() {
+			info.ModeUntil = &mode.Until
+		}
+
+		app.pingsMutex.Lock()
+		record := app.pings[dbID]
+		app.pingsMutex.Unlock()
+		if !record.lastOK.IsZero() {
+			info.LastPingAt = &record.lastOK
+		}
+		if record.lastError != "" {
+			info.LastError = record.lastError
+			info.LastErrorAt = &record.lastErrorAt
+		}
+
+		retryAt, backingOff := app.nextRetry(dbID)
+		db, connected := dbs[dbID]
+		switch {
+		case mode.Name == config.ModeMaintenance:
+			info.State = stateDisabled
+		case connected:
+			info.State = stateConnected
+		case backingOff:
+			info.State = stateCircuitOpen
+			info.NextRetryAt = &retryAt
+		default:
+			info.State = stateReconnecting
+		}
+		info.Available = info.State == stateConnected
+
+		if connected {
+			app.dbMutex.RLock()
+			info.ServerVersion = app.servers[dbID].version
+			app.dbMutex.RUnlock()
+
+			stats := db.Stats()
+			info.Pool = &PoolStats{
+				MaxOpenConnections: stats.MaxOpenConnections,
+				OpenConnections:    stats.OpenConnections,
+				InUse:              stats.InUse,
+				Idle:               stats.Idle,
+				WaitCount:          stats.WaitCount,
+				WaitDuration:       stats.WaitDuration.String(),
+			}
+		}
+		databases = append(databases, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"databases": databases,
+		"count":     len(databases),
+		"aliases":   aliases,
+		"groups":    groups,
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+// listedDatabase is an entry of the /databases listing
+type listedDatabase struct {
+	ID          string     `json:"id"`
+	State       string     `json:"state"`
+	Available   bool       `json:"available"`
+	Service     string     `json:"service"`
+	LastPingAt  *time.Time `json:"last_ping_at"`
+	LastError   string     `json:"last_error"`
+	NextRetryAt *time.Time `json:"next_retry_at"`
+	Pool        *struct {
+		OpenConnections int `json:"open_connections"`
+	} `json:"pool"`
+}
+
+func listDatabases(t *testing.T, router http.Handler, path string) map[string]listedDatabase {
+	t.Helper()
+	w := serve(router, "GET", path, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Databases []listedDatabase `json:"databases"`
+		Count     int              `json:"count"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	listed := make(map[string]listedDatabase, len(response.Databases))
+	for _, db := range response.Databases {
+		listed[db.ID] = db
+	}
+	if len(listed) != response.Count {
+		t.Errorf("expected count %d to match the listing, got %+v", response.Count, response.Databases)
+	}
+	return listed
+}
+
+// ============================================================================
+// Database Listing Tests
+// ============================================================================
+
+func TestListDatabases_IncludesDatabasesThatAreDown(t *testing.T) {
+	// Arrange: sales and finance are connected, hr was never dialled
+	app := newFanOutTestApp(t)
+	finance := app.cfg.Databases["finance"]
+	finance.Mode.Name = config.ModeMaintenance
+	app.cfg.Databases["finance"] = finance
+	router := app.mount()
+
+	// Act
+	listed := listDatabases(t, router, "/databases")
+
+	// Assert
+	if len(listed) != 3 {
+		t.Fatalf("expected every configured database, got %+v", listed)
+	}
+	sales := listed["sales"]
+	if sales.State != stateConnected || !sales.Available || sales.LastPingAt == nil || sales.Pool == nil {
+		t.Errorf("expected sales connected with its pool, got %+v", sales)
+	}
+	if sales.Service == "" {
+		t.Errorf("expected the sales file name as service, got %+v", sales)
+	}
+	if got := listed["finance"].State; got != stateDisabled {
+		t.Errorf("expected finance disabled for maintenance, got %s", got)
+	}
+	if hr := listed["hr"]; hr.State != stateReconnecting || hr.Available || hr.Pool != nil {
+		t.Errorf("expected hr to be dialled by the next request, got %+v", hr)
+	}
+}
+
+func TestListDatabases_Probe(t *testing.T) {
+	// Arrange
+	app := newFanOutTestApp(t)
+	router := app.mount()
+
+	// Act
+	listed := listDatabases(t, router, "/databases?probe=true")
+
+	// Assert: the failed dial opens the circuit for the backoff
+	hr := listed["hr"]
+	if hr.State != stateCircuitOpen || hr.LastError == "" || hr.NextRetryAt == nil {
+		t.Fatalf("expected hr's circuit open after the probe, got %+v", hr)
+	}
+	if until := time.Until(*hr.NextRetryAt); until <= 0 || until > reconnectBackoff {
+		t.Errorf("expected the next retry within %v, got %v", reconnectBackoff, until)
+	}
+	if sales := listed["sales"]; sales.State != stateConnected {
+		t.Errorf("expected sales still connected, got %+v", sales)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/auth"
+	"github.com/hotbrandon/go-chi/internal/fanout"
+	"github.com/hotbrandon/go-chi/internal/handlers"
+	"github.com/hotbrandon/go-chi/internal/logging"
+	"github.com/hotbrandon/go-chi/internal/ratelimit"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// Rows each database may return to a fan-out listing, which reads the
+// first page*page_size rows of every database to merge them
+const maxFanOutRows = 1000
+
+// fanOutStatus reports how one database took part in a fan-out request
+type fanOutStatus struct {
+	ID         string  `json:"id"`
+	Status     string  `json:"status"` // "ok" or "error"
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// sourcedTransaction is a transaction tagged with its database
+type sourcedTransaction struct {
+	DatabaseID string `json:"database_id"`
+	repo.Transaction
+}
+
+// summedHolding is a holding summed over the databases holding the coin
+type summedHolding struct {
+	repo.Holding
+	DatabaseIDs []string `json:"database_ids"`
+}
+
+var errRateLimited = errors.New("database rate limit exceeded")
+
+// takeDatabaseToken takes a token from the per-database bucket of dbID, as
+// a request to /api/{database_id} would. Like ratelimit.Middleware, it lets
+// the request through when the store fails.
+func (app *application) takeDatabaseToken(ctx context.Context, dbID string) bool {
+	limit := app.rateLimitRules("api")().PerDatabase
+	if !limit.Enabled() {
+		return true
+	}
+	decision, err := app.rateLimits.Take(ctx, ratelimit.DatabaseKey("api", dbID), limit)
+	if err != nil {
+		slog.Warn("rate limiter unavailable, allowing request",
+			"database_id", dbID,
+			"error", err)
+		return true
+	}
+	return decision.Allowed
+}
+
+// fanOut runs call against every database the caller may call pattern on
+// (a route below /api/{database_id}) and writes the per-database statuses.
+// It responds itself, and returns nil, when no database could be queried.
+func fanOut[T any](app *application, w http.ResponseWriter, r *http.Request, pattern string,
+	call func(ctx context.Context, repository *repo.Repository) (T, error)) ([]fanout.Result[T], []fanOutStatus) {
+	ids := app.fanOutDatabases(r, pattern)
+	if len(ids) == 0 {
+		apierror.Write(w, http.StatusForbidden,
+			"Forbidden",
+			"You do not have access to any database.",
+			"FORBIDDEN")
+		return nil, nil
+	}
+
+	app.cfgMutex.RLock()
+	opts := fanout.Options{Timeout: app.cfg.FanOut.Timeout, MaxConcurrency: app.cfg.FanOut.MaxConcurrency}
+	app.cfgMutex.RUnlock()
+
+	results := fanout.Run(r.Context(), ids, opts, func(ctx context.Context, dbID string) (T, error) {
+		var zero T
+		dbConfig, exists := app.databaseConfig(dbID)
+		if !exists {
+			return zero, errors.New("database is no longer configured")
+		}
+		if mode, _ := app.databaseMode(dbID, dbConfig); !modeAllows(mode, http.MethodGet) {
+			return zero, errMaintenance
+		}
+		if !app.takeDatabaseToken(ctx, dbID) {
+			return zero, errRateLimited
+		}
+		release, err := app.admissionController(dbID).Acquire(ctx)
+		if err != nil {
+			return zero, err
+		}
+		defer release()
+		db, err := app.getOrConnectDB(ctx, dbID)
+		if err != nil {
+			return zero, err
+		}
+
+		repository, err := app.repository(ctx, dbID, db, dbConfig, replicaReads(r))
+		if err != nil {
+			return zero, err
+		}
+		return call(ctx, repository)
+	})
+
+	log := logging.FromContext(r.Context())
+	statuses := make([]fanOutStatus, len(results))
+	failed := 0
+	for i, result := range results {
+		statuses[i] = fanOutStatus{ID: result.DatabaseID, Status: "ok", DurationMs: milliseconds(result.Duration)}
+		if result.Err != nil {
+			failed++
+			statuses[i].Status = "error"
+			statuses[i].Error = app.redactor.String(result.Err.Error())
+			log.Warn("database failed in fan-out request",
+				"database_id", result.DatabaseID,
+				"error", result.Err)
+		}
+	}
+	if failed == len(results) {
+		// The standard error body, with what went wrong on each database
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(struct {
+			apierror.Response
+			Databases []fanOutStatus `json:"databases"`
+		}{
+			Response: apierror.Response{
+				Error:   "Databases Unavailable",
+				Message: "No database answered the request. Please try again later.",
+				Code:    "DB_UNAVAILABLE",
+			},
+			Databases: statuses,
+		})
+		return nil, nil
+	}
+	return results, statuses
+}
+
+// fanOutDatabases returns the configured databases, sorted, on which the
+// caller may GET pattern: those of the group the request was routed to, or
+// else all of them. Without authentication the caller may use any.
+func (app *application) fanOutDatabases(r *http.Request, pattern string) []string {
+	ids, isGroup := groupMembers(r.Context())
+	if !isGroup {
+		app.cfgMutex.RLock()
+		ids = app.cfg.DatabaseIDs()
+		app.cfgMutex.RUnlock()
+	}
+	if !app.authEnabled() {
+		return ids
+	}
+
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+	var allowed []string
+	for _, dbID := range ids {
+		if principal.CanAccess(dbID, auth.AccessRead) && app.policy.Allows(principal, dbID, http.MethodGet, pattern) {
+			allowed = append(allowed, dbID)
+		}
+	}
+	return allowed
+}
+
+// allTransactionsHandler lists the transactions of every database, newest
+// first, as if they were one table.
+func (app *application) allTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := handlers.Pagination(r)
+	if page*pageSize > maxFanOutRows {
+		apierror.Write(w, http.StatusBadRequest,
+			"Page Too Deep",
+			"Listings across databases are limited to the first "+strconv.Itoa(maxFanOutRows)+" transactions.",
+			"PAGE_TOO_DEEP")
+		return
+	}
+
+	// Any row of the page may come from any database, so each returns
+	// everything up to the end of the page
+	results, statuses := fanOut(app, w, r, "/crypto/transactions",
+		func(ctx context.Context, repository *repo.Repository) ([]repo.Transaction, error) {
+			return repository.ListTransactions(ctx, 1, page*pageSize)
+		})
+	if results == nil {
+		return
+	}
+
+	var merged []sourcedTransaction
+	for _, result := range results {
+		for _, t := range result.Value {
+			merged = append(merged, sourcedTransaction{DatabaseID: result.DatabaseID, Transaction: t})
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if a.TransactionDate != b.TransactionDate {
+			return a.TransactionDate > b.TransactionDate
+		}
+		if a.DatabaseID != b.DatabaseID {
+			return a.DatabaseID < b.DatabaseID
+		}
+		return a.TransactionsSeq > b.TransactionsSeq
+	})
+
+	start := min((page-1)*pageSize, len(merged))
+	end := min(start+pageSize, len(merged))
+	writeFanOut(w, statuses, map[string]interface{}{
+		"transactions": append([]sourcedTransaction{}, merged[start:end]...),
+		"page":         page,
+		"page_size":    pageSize,
+	})
+}
+
+// allHoldingsHandler sums the holdings of every database per coin.
+func (app *application) allHoldingsHandler(w http.ResponseWriter, r *http.Request) {
+	results, statuses := fanOut(app, w, r, "/crypto/holdings",
+		func(ctx context.Context, repository *repo.Repository) ([]repo.Holding, error) {
+			return repository.Holdings(ctx)
+		})
+	if results == nil {
+		return
+	}
+
+	byCoin := make(map[string]*summedHolding)
+	for _, result := range results {
+		for _, h := range result.Value {
+			sum, exists := byCoin[h.CoinSymbol]
+			if !exists {
+				sum = &summedHolding{Holding: repo.Holding{CoinSymbol: h.CoinSymbol}}
+				byCoin[h.CoinSymbol] = sum
+			}
+			sum.Quantity += h.Quantity
+			sum.TotalBought += h.TotalBought
+			sum.TotalSold += h.TotalSold
+			sum.Transactions += h.Transactions
+			sum.DatabaseIDs = append(sum.DatabaseIDs, result.DatabaseID)
+		}
+	}
+	holdings := make([]summedHolding, 0, len(byCoin))
+	for _, sum := range byCoin {
+		holdings = append(holdings, *sum)
+	}
+	sort.Slice(holdings, func(i, j int) bool { return holdings[i].CoinSymbol < holdings[j].CoinSymbol })
+
+	writeFanOut(w, statuses, map[string]interface{}{
+		"holdings": holdings,
+	})
+}
+
+// writeFanOut writes a fan-out response: body plus the status of each
+// database, and whether any failed
+func writeFanOut(w http.ResponseWriter, statuses []fanOutStatus, body map[string]interface{}) {
+	partial := false
+	for _, s := range statuses {
+		partial = partial || s.Status != "ok"
+	}
+	body["databases"] = statuses
+	body["partial"] = partial
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
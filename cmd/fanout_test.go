@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// sqliteDatabase configures an in-memory SQLite database private to the test
+func sqliteDatabase(t *testing.T, dbID string) config.DatabaseConfig {
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name()) + "_" + dbID
+	return config.DatabaseConfig{Driver: "sqlite", DSN: "file:" + name + "?mode=memory&cache=shared"}
+}
+
+// seedDatabase connects dbID, migrates it and inserts transactions
+func seedDatabase(t *testing.T, app *application, dbID string, transactions ...repo.Transaction) {
+	t.Helper()
+	db, err := app.connectDatabase(dbID)
+	if err != nil {
+		t.Fatalf("failed to connect %s: %v", dbID, err)
+	}
+	migrator, err := newMigrator(dbID, db, repo.SQLite, time.Second)
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("failed to migrate %s: %v", dbID, err)
+	}
+	for _, tx := range transactions {
+		if err := repo.New(db, repo.SQLite).CreateTransaction(context.Background(), tx); err != nil {
+			t.Fatalf("failed to seed %s: %v", dbID, err)
+		}
+	}
+}
+
+func buy(coin string, quantity, cost float64, date string) repo.Transaction {
+	return repo.Transaction{CoinSymbol: coin, TransactionType: "B", Quantity: quantity,
+		PricePerUnit: cost / quantity, TotalCost: cost, TransactionDate: date, Exchange: "BN"}
+}
+
+// newFanOutTestApp returns an app with two seeded databases and one that
+// cannot be opened
+func newFanOutTestApp(t *testing.T) *application {
+	t.Helper()
+	captureLogs(t, nil)
+	app := newTestApp(map[string]config.DatabaseConfig{
+		"sales":   sqliteDatabase(t, "sales"),
+		"finance": sqliteDatabase(t, "finance"),
+		"hr":      {Driver: "sqlite", DSN: "file:/nonexistent/hr.db?mode=ro"},
+	})
+	app.cfg.FanOut = config.FanOutConfig{Timeout: 5 * time.Second, MaxConcurrency: 2}
+
+	seedDatabase(t, app, "sales",
+		buy("BTC", 1, 100, "2024-01-10"),
+		buy("ETH", 2, 20, "2024-03-01"))
+	seedDatabase(t, app, "finance",
+		buy("BTC", 0.5, 60, "2024-02-01"))
+	return app
+}
+
+// fanOutResponse is the body of the /api/_all endpoints
+type fanOutResponse struct {
+	Transactions []sourcedTransaction `json:"transactions"`
+	Holdings     []summedHolding      `json:"holdings"`
+	Databases    []fanOutStatus       `json:"databases"`
+	Partial      bool                 `json:"partial"`
+	Code         string               `json:"code"`
+}
+
+func decodeFanOut(t *testing.T, body io.Reader) fanOutResponse {
+	t.Helper()
+	var response fanOutResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return response
+}
+
+// ============================================================================
+// Fan-out Tests
+// ============================================================================
+
+func TestFanOut_Transactions(t *testing.T) {
+	// Arrange
+	app := newFanOutTestApp(t)
+	router := app.mount()
+
+	// Act
+	w := serve(router, "GET", "/api/_all/crypto/transactions?page_size=2", "", nil)
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeFanOut(t, w.Body)
+
+	// Newest first across databases, tagged with their source
+	var got []string
+	for _, tx := range response.Transactions {
+		got = append(got, tx.DatabaseID+" "+tx.CoinSymbol+" "+tx.TransactionDate[:10])
+	}
+	want := []string{"sales ETH 2024-03-01", "finance BTC 2024-02-01"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// The unreachable database is reported, not fatal
+	if !response.Partial || len(response.Databases) != 3 {
+		t.Fatalf("expected a partial response over 3 databases, got %+v", response)
+	}
+	for _, status := range response.Databases {
+		if wantStatus := map[bool]string{true: "error", false: "ok"}[status.ID == "hr"]; status.Status != wantStatus {
+			t.Errorf("expected %s to be %s, got %+v", status.ID, wantStatus, status)
+		}
+	}
+
+	// The second page continues the merge
+	w = serve(router, "GET", "/api/_all/crypto/transactions?page=2&page_size=2", "", nil)
+	response = decodeFanOut(t, w.Body)
+	if len(response.Transactions) != 1 || response.Transactions[0].DatabaseID != "sales" {
+		t.Errorf("expected the oldest sales transaction on page 2, got %+v", response.Transactions)
+	}
+}
+
+func TestFanOut_Holdings(t *testing.T) {
+	// Arrange
+	app := newFanOutTestApp(t)
+	router := app.mount()
+
+	// Act
+	w := serve(router, "GET", "/api/_all/crypto/holdings", "", nil)
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeFanOut(t, w.Body)
+	if len(response.Holdings) != 2 {
+		t.Fatalf("expected BTC and ETH, got %+v", response.Holdings)
+	}
+	btc := response.Holdings[0]
+	if btc.CoinSymbol != "BTC" || btc.Quantity != 1.5 || btc.TotalBought != 160 || btc.Transactions != 2 {
+		t.Errorf("expected BTC summed over both databases, got %+v", btc)
+	}
+	if strings.Join(btc.DatabaseIDs, ",") != "finance,sales" {
+		t.Errorf("expected BTC from finance and sales, got %v", btc.DatabaseIDs)
+	}
+}
+
+func TestFanOut_AllDatabasesFail(t *testing.T) {
+	captureLogs(t, nil)
+	app := newTestApp(map[string]config.DatabaseConfig{
+		"hr": {Driver: "sqlite", DSN: "file:/nonexistent/hr.db?mode=ro"},
+	})
+	app.cfg.FanOut = config.FanOutConfig{Timeout: time.Second, MaxConcurrency: 1}
+
+	w := serve(app.mount(), "GET", "/api/_all/crypto/holdings", "", nil)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeFanOut(t, w.Body)
+	if response.Code != "DB_UNAVAILABLE" || len(response.Databases) != 1 || response.Databases[0].Error == "" {
+		t.Errorf("expected the failure of hr to be reported, got %+v", response)
+	}
+}
+
+func TestFanOut_OnlyPermittedDatabases(t *testing.T) {
+	// Arrange
+	captureLogs(t, nil)
+	app, _ := newAuthTestApp(t)
+	app.cfg.FanOut = config.FanOutConfig{Timeout: time.Second, MaxConcurrency: 2}
+	viewer := mintTestKey(t, app, []string{"sales:read"})
+	router := app.mount()
+
+	// Act
+	w := serve(router, "GET", "/api/_all/crypto/holdings", viewer, nil)
+	unauthenticated := serve(router, "GET", "/api/_all/crypto/holdings", "", nil)
+
+	// Assert: only sales was tried (and is unreachable)
+	response := decodeFanOut(t, w.Body)
+	if len(response.Databases) != 1 || response.Databases[0].ID != "sales" {
+		t.Errorf("expected only sales to be queried, got %+v", response.Databases)
+	}
+	if unauthenticated.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key, got %d", unauthenticated.Code)
+	}
+}
+
+func TestFanOut_PerDatabaseRateLimit(t *testing.T) {
+	// Arrange
+	app := newFanOutTestApp(t)
+	app.cfg.RateLimits = map[string]config.RateLimitConfig{
+		"api": {PerDatabase: config.LimitConfig{Rate: 0.001, Burst: 1}},
+	}
+	router := app.mount()
+
+	// Act: the first request takes the only token of each database
+	first := serve(router, "GET", "/api/_all/crypto/holdings", "", nil)
+	second := serve(router, "GET", "/api/_all/crypto/holdings", "", nil)
+	direct := serve(router, "GET", "/api/sales/crypto/holdings", "", nil)
+
+	// Assert
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", first.Code, first.Body.String())
+	}
+	if second.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 once every database is limited, got %d: %s", second.Code, second.Body.String())
+	}
+	response := decodeFanOut(t, second.Body)
+	if len(response.Databases) != 3 {
+		t.Fatalf("expected 3 databases, got %+v", response.Databases)
+	}
+	for _, status := range response.Databases {
+		if status.Status != "error" || !strings.Contains(status.Error, "rate limit") {
+			t.Errorf("expected %s to be rate limited, got %+v", status.ID, status)
+		}
+	}
+
+	// The buckets are those of /api/{database_id}
+	if direct.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 on sales directly, got %d", direct.Code)
+	}
+}
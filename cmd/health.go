@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/config"
+	"github.com/hotbrandon/go-chi/internal/fanout"
+)
+
+// databaseHealth is how one database fared in a readiness check
+type databaseHealth struct {
+	ID        string `json:"id"`
+	Mode      string `json:"mode"`
+	Critical  bool   `json:"critical"`
+	Available bool   `json:"available"`
+	Latency   string `json:"latency,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status         string           `json:"status"`    // "ready", "degraded", "not_ready"
+	Timestamp      string           `json:"timestamp"` // When the databases were checked
+	Databases      []databaseHealth `json:"databases"`
+	TotalDBs       int              `json:"total_databases"`
+	HealthyDBs     int              `json:"healthy_databases"`
+	MaintenanceDBs int              `json:"maintenance_databases"`
+}
+
+// readinessCache holds the last readiness check, which probes reuse while
+// it is fresh
+type readinessCache struct {
+	mu       sync.Mutex
+	checked  time.Time
+	response readinessResponse
+}
+
+// Health checks remain as application methods (they're infrastructure concerns).
+// This one is also the liveness probe, so it never touches the databases: an
+// outage must not get the process restarted.
+func (app *application) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "ok",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// Startup probe - fails until the databases have been dialled and migrated
+func (app *application) startupCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.started.Load() {
+		writeStarting(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "started",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// Readiness check - tests all database connections
+func (app *application) readinessCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.started.Load() {
+		writeStarting(w)
+		return
+	}
+
+	health := app.checkReadiness(r.Context())
+
+	statusCode := http.StatusOK
+	if health.Status == "not_ready" {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(health)
+}
+
+// checkReadiness pings every configured database at once, within
+// health.timeout overall, or returns the last result if it is younger than
+// health.cache_ttl. Probes arriving during a check wait for its result.
+func (app *application) checkReadiness(ctx context.Context) readinessResponse {
+	app.cfgMutex.RLock()
+	settings := app.cfg.Health
+	configs := make(map[string]config.DatabaseConfig, len(app.cfg.Databases))
+	for id, dbConfig := range app.cfg.Databases {
+		configs[id] = dbConfig
+	}
+	ids := app.cfg.DatabaseIDs()
+	app.cfgMutex.RUnlock()
+
+	app.readiness.mu.Lock()
+	defer app.readiness.mu.Unlock()
+	if time.Since(app.readiness.checked) < settings.CacheTTL {
+		return app.readiness.response
+	}
+
+	critical := make(map[string]bool, len(settings.Critical))
+	for _, dbID := range settings.Critical {
+		critical[dbID] = true
+	}
+
+	health := readinessResponse{
+		Status:    "ready",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Databases: make([]databaseHealth, len(ids)),
+		TotalDBs:  len(ids),
+	}
+
+	// Databases in maintenance are expected to be down, so neither pinged
+	// nor held against readiness
+	var pinged []string
+	for i, dbID := range ids {
+		mode, _ := app.databaseMode(dbID, configs[dbID])
+		health.Databases[i] = databaseHealth{ID: dbID, Mode: mode.Name, Critical: critical[dbID]}
+		if mode.Name == config.ModeMaintenance {
+			health.MaintenanceDBs++
+			continue
+		}
+		pinged = append(pinged, dbID)
+	}
+
+	// A client that gives up must not cut short the check others will reuse
+	results := fanout.Run(context.WithoutCancel(ctx), pinged, fanout.Options{Timeout: settings.Timeout},
+		func(ctx context.Context, dbID string) (struct{}, error) {
+			return struct{}{}, app.pingDatabase(ctx, dbID)
+		})
+	byID := make(map[string]fanout.Result[struct{}], len(results))
+	for _, result := range results {
+		byID[result.DatabaseID] = result
+	}
+
+	criticalDown := false
+	for i := range health.Databases {
+		dbHealth := &health.Databases[i]
+		result, checked := byID[dbHealth.ID]
+		if !checked {
+			continue
+		}
+		dbHealth.Available = result.Err == nil
+		if result.Err == nil {
+			dbHealth.Latency = result.Duration.String()
+			health.HealthyDBs++
+		} else {
+			dbHealth.Error = app.redactor.String(result.Err.Error())
+			criticalDown = criticalDown || dbHealth.Critical
+		}
+	}
+
+	// Determine overall status. With critical databases configured only
+	// they decide readiness; otherwise any healthy database will do. With
+	// every database in maintenance the service is still ready, to tell
+	// clients so.
+	switch {
+	case criticalDown:
+		health.Status = "not_ready"
+	case len(critical) == 0 && health.HealthyDBs == 0 && (len(pinged) > 0 || len(ids) == 0):
+		health.Status = "not_ready"
+	case health.HealthyDBs < len(pinged):
+		health.Status = "degraded"
+	}
+
+	app.readiness.checked, app.readiness.response = time.Now(), health
+	return health
+}
+
+// pingDatabase checks dbID through getOrConnectDB, which redials a broken
+// pool unless backing off, and stops waiting for the answer when ctx ends.
+// The check itself carries on, so that its outcome still reaches
+// /databases.
+func (app *application) pingDatabase(ctx context.Context, dbID string) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := app.getOrConnectDB(ctx, dbID)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("no answer in time: %w", ctx.Err())
+	}
+}
+
+func writeStarting(w http.ResponseWriter) {
+	apierror.Write(w, http.StatusServiceUnavailable,
+		"Starting",
+		"The service is still connecting to its databases.",
+		"STARTING")
+}
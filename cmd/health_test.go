@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+// stallConnector opens connections that never come up, like a database
+// whose network has gone quiet
+type stallConnector struct{}
+
+func (stallConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (stallConnector) Driver() driver.Driver { return nil }
+
+func readiness(t *testing.T, router http.Handler) (int, readinessResponse) {
+	t.Helper()
+	w := serve(router, "GET", "/health/readiness", "", nil)
+	var response readinessResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return w.Code, response
+}
+
+// ============================================================================
+// Health Probe Tests
+// ============================================================================
+
+func TestReadiness_StalledDatabasesShareTheDeadline(t *testing.T) {
+	// Arrange: five pools that hang on ping, and one healthy database
+	app := newFanOutTestApp(t)
+	app.cfg.Health = config.HealthConfig{Timeout: 200 * time.Millisecond}
+	for i := range 5 {
+		dbID := fmt.Sprintf("stalled%d", i)
+		app.cfg.Databases[dbID] = config.DatabaseConfig{ID: dbID, Driver: "sqlite", DSN: "file:/nonexistent/" + dbID + ".db?mode=ro"}
+		db := sql.OpenDB(stallConnector{})
+		t.Cleanup(func() { db.Close() })
+		app.dbs[dbID] = db
+	}
+	router := app.mount()
+
+	// Act
+	start := time.Now()
+	code, health := readiness(t, router)
+	elapsed := time.Since(start)
+
+	// Assert
+	if elapsed > time.Second {
+		t.Errorf("expected one deadline for all databases, took %v", elapsed)
+	}
+	if code != http.StatusOK || health.Status != "degraded" || health.HealthyDBs != 2 {
+		t.Errorf("expected degraded with sales and finance healthy, got %d %+v", code, health)
+	}
+	for _, db := range health.Databases {
+		if db.ID == "stalled0" && db.Error == "" {
+			t.Errorf("expected the stalled database to time out, got %+v", db)
+		}
+	}
+}
+
+func TestReadiness_CriticalDatabases(t *testing.T) {
+	tests := []struct {
+		name       string
+		critical   []string
+		wantCode   int
+		wantStatus string
+	}{
+		{"none", nil, http.StatusOK, "degraded"},
+		{"healthy", []string{"sales"}, http.StatusOK, "degraded"},
+		{"down", []string{"sales", "hr"}, http.StatusServiceUnavailable, "not_ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: hr cannot be opened
+			app := newFanOutTestApp(t)
+			app.cfg.Health.Critical = tt.critical
+
+			// Act
+			code, health := readiness(t, app.mount())
+
+			// Assert
+			if code != tt.wantCode || health.Status != tt.wantStatus {
+				t.Errorf("expected %d %s, got %d %s", tt.wantCode, tt.wantStatus, code, health.Status)
+			}
+		})
+	}
+}
+
+func TestReadiness_Cached(t *testing.T) {
+	// Arrange
+	app := newFanOutTestApp(t)
+	app.cfg.Health.CacheTTL = time.Minute
+	router := app.mount()
+	_, first := readiness(t, router)
+
+	// Act: a change within the TTL goes unseen
+	app.modes["sales"] = config.ModeConfig{Name: config.ModeMaintenance}
+	_, cached := readiness(t, router)
+	app.readiness.checked = time.Time{}
+	_, fresh := readiness(t, router)
+
+	// Assert
+	if cached.MaintenanceDBs != 0 || cached.Timestamp != first.Timestamp {
+		t.Errorf("expected the first result again, got %+v", cached)
+	}
+	if fresh.MaintenanceDBs != 1 {
+		t.Errorf("expected a new check once expired, got %+v", fresh)
+	}
+}
+
+func TestStartupProbe(t *testing.T) {
+	// Arrange
+	app := newFanOutTestApp(t)
+	app.started.Store(false)
+	router := app.mount()
+
+	// Act & Assert: while starting, only liveness passes
+	for path, want := range map[string]int{
+		"/health/liveness":  http.StatusOK,
+		"/health/startup":   http.StatusServiceUnavailable,
+		"/health/readiness": http.StatusServiceUnavailable,
+	} {
+		if w := serve(router, "GET", path, "", nil); w.Code != want {
+			t.Errorf("%s: expected %d while starting, got %d", path, want, w.Code)
+		}
+	}
+
+	app.started.Store(true)
+	if w := serve(router, "GET", "/health/startup", "", nil); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once started, got %d", w.Code)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+func TestMetrics_Endpoint(t *testing.T) {
+	captureLogs(t, nil)
+	app := newTestApp(map[string]config.DatabaseConfig{
+		// Nothing listens on port 1, so connecting fails fast
+		"sales": {Host: "127.0.0.1", Port: 1, SID: "SALES", User: "u", Password: "p"},
+	})
+	router := app.mount()
+
+	// The first request attempts a connection; the second is refused by
+	// the backoff without another attempt
+	for i := 0; i < 2; i++ {
+		if w := serve(router, "GET", "/api/sales/crypto/transactions", "", nil); w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("request %d: expected 503, got %d", i+1, w.Code)
+		}
+	}
+
+	w := serve(router, "GET", "/metrics", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 from /metrics, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		`db_reconnect_attempts_total{database_id="sales"} 1`,
+		`db_reconnect_failures_total{database_id="sales"} 1`,
+		`http_requests_total{database_id="sales",method="GET",route="/api/{database_id}/*",status="503"} 2`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected metrics to contain %q", line)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/migrate"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+const migrateUsage = `  up        apply pending migrations (default)
+  down      revert the last -steps migrations
+  status    list applied and pending migrations
+  baseline  record migrations up to -to as applied without running them
+  unlock    release a lock left by a migration that was killed
+
+`
+
+// newMigrator returns a migrator applying the embedded migrations of the
+// database's dialect to db
+func newMigrator(dbID string, db *sql.DB, d repo.Dialect, lockTimeout time.Duration) (*migrate.Migrator, error) {
+	migrations, err := migrate.Embedded(d.Name())
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	return migrate.New(db, migrations, migrate.Options{
+		Dialect:     d,
+		DatabaseID:  dbID,
+		Owner:       fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		LockTimeout: lockTimeout,
+	})
+}
+
+// migrateOnStartup applies pending migrations to the selected databases
+// before serving. Databases that could not be reached are skipped, as they
+// are when serving; a failed migration stops startup.
+func (app *application) migrateOnStartup() error {
+	app.cfgMutex.RLock()
+	ids := app.cfg.MigrationDatabaseIDs()
+	lockTimeout := app.cfg.Migrations.LockTimeout
+	dialects := make(map[string]repo.Dialect, len(ids))
+	for _, dbID := range ids {
+		dialects[dbID] = dialect(app.cfg.Databases[dbID])
+	}
+	app.cfgMutex.RUnlock()
+
+	dbs := app.connectedDBs()
+	for _, dbID := range ids {
+		db, connected := dbs[dbID]
+		if !connected {
+			slog.Warn("database unavailable, skipping migrations", "database_id", dbID)
+			continue
+		}
+		migrator, err := newMigrator(dbID, db, dialects[dbID], lockTimeout)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dbID, err)
+		}
+		// The lock wait is bounded by the lock timeout; migrations
+		// themselves run as long as they need
+		applied, err := migrator.Up(context.Background(), 0)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dbID, err)
+		}
+		slog.Info("database migrated", "database_id", dbID, "applied", len(applied))
+	}
+	return nil
+}
+
+// runMigrate implements the migrate subcommand against every selected
+// database, stopping at the first failure.
+func runMigrate(c *cli, args []string) error {
+	action := "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	flags, configPath := c.flagSet("migrate [up|down|status|baseline|unlock]", migrateUsage)
+	databases := flags.String("databases", "", "comma-separated database ids (default: migrations.databases, or all)")
+	to := flags.Int("to", 0, "up: last version to apply (default: all); baseline: last version to record")
+	steps := flags.Int("steps", 1, "down: number of migrations to revert")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	switch action {
+	case "up", "down", "status", "unlock":
+	case "baseline":
+		if *to < 1 {
+			return errors.New("baseline requires -to <version>")
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command %q", action)
+	}
+
+	cfg, _, err := c.loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	ids, err := selectDatabases(cfg, *databases, cfg.MigrationDatabaseIDs())
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, dbID := range ids {
+		db, err := openCLIDatabase(cfg, dbID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dbID, err)
+		}
+		err = migrateDatabase(ctx, action, dbID, db, dialect(cfg.Databases[dbID]), cfg.Migrations.LockTimeout, *to, *steps, c.out)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", dbID, err)
+		}
+	}
+	return nil
+}
+
+// migrateDatabase runs one migrate command against one database and prints
+// the outcome
+func migrateDatabase(ctx context.Context, action, dbID string, db *sql.DB, d repo.Dialect, lockTimeout time.Duration, to, steps int, out io.Writer) error {
+	migrator, err := newMigrator(dbID, db, d, lockTimeout)
+	if err != nil {
+		return err
+	}
+
+	var changed []migrate.Migration
+	switch action {
+	case "up":
+		changed, err = migrator.Up(ctx, to)
+	case "down":
+		changed, err = migrator.Down(ctx, steps)
+	case "baseline":
+		changed, err = migrator.Baseline(ctx, to)
+	case "unlock":
+		if err := migrator.Unlock(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s: lock released\n", dbID)
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\tVERSION\tNAME\tAPPLIED AT\tPROBLEM\n", dbID)
+		for _, s := range statuses {
+			appliedAt, problem := "pending", ""
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format(time.DateTime)
+			}
+			if s.Problem != nil {
+				problem = s.Problem.Error()
+			}
+			fmt.Fprintf(w, "\t%d\t%s\t%s\t%s\n", s.Version, s.Name, appliedAt, problem)
+		}
+		return w.Flush()
+	}
+
+	// Report what was done before a failure too
+	done := map[string]string{"up": "applied", "down": "reverted", "baseline": "recorded"}[action]
+	for _, m := range changed {
+		fmt.Fprintf(out, "%s: %s %04d_%s\n", dbID, done, m.Version, m.Name)
+	}
+	if err == nil && len(changed) == 0 {
+		fmt.Fprintf(out, "%s: no migrations %s\n", dbID, done)
+	}
+	return err
+}
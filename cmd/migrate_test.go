@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/redact"
+)
+
+func TestRunMigrate_InvalidArguments(t *testing.T) {
+	captureLogs(t, nil)
+	t.Setenv("ORA_SALES_DSN", "oracle://u:p@127.0.0.1:1/SALES")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown command", []string{"sideways"}, "unknown migrate command"},
+		{"baseline without version", []string{"baseline"}, "requires -to"},
+		{"stray argument", []string{"up", "-steps", "2", "extra"}, "unexpected arguments"},
+		{"unknown database", []string{"status", "-databases", "sales,hr"}, `"hr" is not configured`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runMigrate(&cli{redactor: redact.New(), out: io.Discard}, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
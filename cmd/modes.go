@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+// Where the mode of a database was set
+const (
+	modeSourceConfig = "config"
+	modeSourceAdmin  = "admin"
+)
+
+var errMaintenance = errors.New("database is down for maintenance")
+
+// databaseMode returns the mode dbID is in: the one set through the admin
+// API, which lasts until cleared or the process restarts, or else the
+// configured one.
+func (app *application) databaseMode(dbID string, dbConfig config.DatabaseConfig) (mode config.ModeConfig, source string) {
+	app.modesMutex.RLock()
+	defer app.modesMutex.RUnlock()
+	if mode, exists := app.modes[dbID]; exists {
+		return mode, modeSourceAdmin
+	}
+	return dbConfig.Mode, modeSourceConfig
+}
+
+// removeMode forgets the mode set on a removed database.
+func (app *application) removeMode(dbID string) {
+	app.modesMutex.Lock()
+	defer app.modesMutex.Unlock()
+	delete(app.modes, dbID)
+}
+
+// modeAllows reports whether a database in mode serves requests of method.
+// Read-only databases serve those that cannot write.
+func modeAllows(mode config.ModeConfig, method string) bool {
+	switch mode.Name {
+	case config.ModeMaintenance:
+		return false
+	case config.ModeReadOnly:
+		return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	default:
+		return true
+	}
+}
+
+// writeModeUnavailable rejects a request the mode of its database does not
+// allow. Retry-After carries the end of the window, if it is still ahead.
+func writeModeUnavailable(w http.ResponseWriter, mode config.ModeConfig) {
+	if time.Until(mode.Until) > 0 {
+		w.Header().Set("Retry-After", mode.Until.UTC().Format(http.TimeFormat))
+	}
+
+	title, message, code := "Database Read-Only",
+		"The database is read-only for maintenance; writes are disabled. Please try again later.",
+		"DB_READ_ONLY"
+	if mode.Name == config.ModeMaintenance {
+		title, message, code = "Database Maintenance",
+			"The database is down for maintenance. Please try again later.",
+			"DB_MAINTENANCE"
+	}
+	if mode.Message != "" {
+		message = mode.Message
+	}
+	apierror.Write(w, http.StatusServiceUnavailable, title, message, code)
+}
+
+// databaseModeResponse is the mode of a database as the admin API shows it
+type databaseModeResponse struct {
+	DatabaseID string     `json:"database_id"`
+	Mode       string     `json:"mode"`
+	Until      *time.Time `json:"until,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	Source     string     `json:"source"` // "config" or "admin"
+}
+
+// Current mode of a database
+func (app *application) databaseModeHandler(w http.ResponseWriter, r *http.Request) {
+	dbID := strings.ToLower(chi.URLParam(r, "database_id"))
+	dbConfig, exists := app.databaseConfig(dbID)
+	if !exists {
+		writeDatabaseNotFound(w)
+		return
+	}
+	app.writeDatabaseMode(w, dbID, dbConfig)
+}
+
+// Put a database in a mode, overriding the configured one - e.g. read-only
+// for the length of a patch window
+func (app *application) setDatabaseModeHandler(w http.ResponseWriter, r *http.Request) {
+	type SetModeRequest struct {
+		Mode    string    `json:"mode"`
+		Until   time.Time `json:"until"`
+		Message string    `json:"message"`
+	}
+
+	dbID := strings.ToLower(chi.URLParam(r, "database_id"))
+	dbConfig, exists := app.databaseConfig(dbID)
+	if !exists {
+		writeDatabaseNotFound(w)
+		return
+	}
+
+	var req SetModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest,
+			"Invalid Request", "Unable to parse request body.", "INVALID_JSON")
+		return
+	}
+	mode := config.ModeConfig{Name: strings.ToLower(req.Mode), Until: req.Until, Message: req.Message}
+	if err := mode.Validate(); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "Validation Error", err.Error(), "INVALID_MODE")
+		return
+	}
+
+	app.modesMutex.Lock()
+	app.modes[dbID] = mode
+	app.modesMutex.Unlock()
+
+	slog.Info("database mode set",
+		"database_id", dbID,
+		"mode", mode.Name,
+		"until", mode.Until)
+	app.writeDatabaseMode(w, dbID, dbConfig)
+}
+
+// Return a database to its configured mode
+func (app *application) clearDatabaseModeHandler(w http.ResponseWriter, r *http.Request) {
+	dbID := strings.ToLower(chi.URLParam(r, "database_id"))
+	dbConfig, exists := app.databaseConfig(dbID)
+	if !exists {
+		writeDatabaseNotFound(w)
+		return
+	}
+
+	app.removeMode(dbID)
+
+	slog.Info("database mode cleared",
+		"database_id", dbID,
+		"mode", dbConfig.Mode.Name)
+	app.writeDatabaseMode(w, dbID, dbConfig)
+}
+
+func (app *application) writeDatabaseMode(w http.ResponseWriter, dbID string, dbConfig config.DatabaseConfig) {
+	mode, source := app.databaseMode(dbID, dbConfig)
+	response := databaseModeResponse{
+		DatabaseID: dbID,
+		Mode:       mode.Name,
+		Message:    mode.Message,
+		Source:     source,
+	}
+	if !mode.Until.IsZero() {
+		response.Until = &mode.Until
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
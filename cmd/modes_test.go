@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+// ============================================================================
+// Database Mode Tests
+// ============================================================================
+
+func TestModes_ReadOnly(t *testing.T) {
+	// Arrange
+	app := newFanOutTestApp(t)
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	sales := app.cfg.Databases["sales"]
+	sales.Mode = config.ModeConfig{Name: config.ModeReadOnly, Until: until}
+	app.cfg.Databases["sales"] = sales
+	router := app.mount()
+
+	// Act
+	read := serve(router, "GET", "/api/sales/crypto/holdings", "", nil)
+	write := serve(router, "POST", "/api/sales/crypto/transactions", "", buy("BTC", 1, 100, "2024-04-01"))
+
+	// Assert
+	if read.Code != http.StatusOK {
+		t.Errorf("expected reads to be served, got %d: %s", read.Code, read.Body.String())
+	}
+	if write.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for writes, got %d: %s", write.Code, write.Body.String())
+	}
+	var body apierror.Response
+	json.NewDecoder(write.Body).Decode(&body)
+	if body.Code != "DB_READ_ONLY" {
+		t.Errorf("expected DB_READ_ONLY, got %+v", body)
+	}
+	if got, want := write.Header().Get("Retry-After"), until.UTC().Format(http.TimeFormat); got != want {
+		t.Errorf("expected Retry-After %q, got %q", want, got)
+	}
+}
+
+func TestModes_Maintenance(t *testing.T) {
+	// Arrange: the window is over but the mode not yet lifted
+	app := newFanOutTestApp(t)
+	sales := app.cfg.Databases["sales"]
+	sales.Mode = config.ModeConfig{
+		Name:    config.ModeMaintenance,
+		Until:   time.Now().Add(-time.Minute),
+		Message: "Sales is being patched.",
+	}
+	app.cfg.Databases["sales"] = sales
+	router := app.mount()
+
+	// Act
+	read := serve(router, "GET", "/api/sales/crypto/holdings", "", nil)
+	all := serve(router, "GET", "/api/_all/crypto/holdings", "", nil)
+	readiness := serve(router, "GET", "/health/readiness", "", nil)
+	listed := serve(router, "GET", "/databases", "", nil)
+
+	// Assert
+	var body apierror.Response
+	json.NewDecoder(read.Body).Decode(&body)
+	if read.Code != http.StatusServiceUnavailable || body.Code != "DB_MAINTENANCE" || body.Message != "Sales is being patched." {
+		t.Errorf("expected 503 DB_MAINTENANCE with the configured message, got %d %+v", read.Code, body)
+	}
+	if got := read.Header().Get("Retry-After"); got != "" {
+		t.Errorf("expected no Retry-After once the window is over, got %q", got)
+	}
+
+	for _, status := range decodeFanOut(t, all.Body).Databases {
+		if status.ID == "sales" && status.Error != errMaintenance.Error() {
+			t.Errorf("expected sales to be skipped by the fan-out, got %+v", status)
+		}
+	}
+
+	// sales is not pinged, so finance (healthy) and hr (down) decide
+	var health struct {
+		Status         string `json:"status"`
+		HealthyDBs     int    `json:"healthy_databases"`
+		MaintenanceDBs int    `json:"maintenance_databases"`
+	}
+	json.NewDecoder(readiness.Body).Decode(&health)
+	if health.Status != "degraded" || health.HealthyDBs != 1 || health.MaintenanceDBs != 1 {
+		t.Errorf("expected degraded with sales in maintenance, got %+v", health)
+	}
+
+	var databases struct {
+		Databases []struct {
+			ID        string `json:"id"`
+			Available bool   `json:"available"`
+			Mode      string `json:"mode"`
+		} `json:"databases"`
+	}
+	json.NewDecoder(listed.Body).Decode(&databases)
+	for _, db := range databases.Databases {
+		if db.ID == "sales" && (db.Available || db.Mode != config.ModeMaintenance) {
+			t.Errorf("expected sales listed as unavailable for maintenance, got %+v", db)
+		}
+	}
+}
+
+func TestModes_AdminOverride(t *testing.T) {
+	// Arrange
+	app := newFanOutTestApp(t)
+	router := app.mount()
+	var mode databaseModeResponse
+
+	// Act & Assert: the override takes precedence over the configuration
+	w := serve(router, "PUT", "/admin/databases/sales/mode", "", map[string]string{
+		"mode":  "Read_Only",
+		"until": "2030-01-01T06:00:00Z",
+	})
+	json.NewDecoder(w.Body).Decode(&mode)
+	if w.Code != http.StatusOK || mode.Mode != config.ModeReadOnly || mode.Source != modeSourceAdmin {
+		t.Fatalf("expected sales read-only by admin, got %d %+v", w.Code, mode)
+	}
+	w = serve(router, "POST", "/api/sales/crypto/transactions", "", buy("BTC", 1, 100, "2024-04-01"))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "Tue, 01 Jan 2030 06:00:00 GMT" {
+		t.Errorf("expected 503 until 2030, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Clearing it restores the configured mode
+	w = serve(router, "DELETE", "/admin/databases/sales/mode", "", nil)
+	var cleared databaseModeResponse
+	json.NewDecoder(w.Body).Decode(&cleared)
+	if cleared.Mode != config.ModeNormal || cleared.Source != modeSourceConfig || cleared.Until != nil {
+		t.Errorf("expected the configured mode back, got %+v", cleared)
+	}
+	w = serve(router, "POST", "/api/sales/crypto/transactions", "", buy("BTC", 1, 100, "2024-04-01"))
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected writes to be served again, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := serve(router, "PUT", "/admin/databases/sales/mode", "", map[string]string{"mode": "offline"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown mode, got %d", w.Code)
+	}
+	if w := serve(router, "PUT", "/admin/databases/payroll/mode", "", map[string]string{"mode": "maintenance"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown database, got %d", w.Code)
+	}
+}
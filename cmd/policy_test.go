@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/auth"
+)
+
+// mintTestKey mints a key directly in the app's store
+func mintTestKey(t *testing.T, app *application, scopes []string, roles ...string) string {
+	t.Helper()
+	_, key, err := auth.MintKey(context.Background(), app.keyStore, "test", scopes, roles...)
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+	return key
+}
+
+func TestPolicy_Routes(t *testing.T) {
+	captureLogs(t, nil)
+	app, _ := newAuthTestApp(t)
+	router := app.mount()
+
+	viewer := mintTestKey(t, app, []string{"sales:read"})
+	trader := mintTestKey(t, app, []string{"sales:write"})
+	admin := mintTestKey(t, app, []string{"sales:write"}, "sales:admin")
+
+	// Requests the policy allows reach the (unreachable) database: 503
+	const allowed, forbidden = http.StatusServiceUnavailable, http.StatusForbidden
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		want   int
+	}{
+		{"viewer lists", viewer, "GET", "/api/sales/crypto/transactions", allowed},
+		{"viewer gets", viewer, "GET", "/api/sales/crypto/transactions/1", allowed},
+		{"viewer gets holdings", viewer, "GET", "/api/sales/crypto/holdings", allowed},
+		{"viewer cannot create", viewer, "POST", "/api/sales/crypto/transactions", forbidden},
+		{"trader creates", trader, "POST", "/api/sales/crypto/transactions", allowed},
+		{"trader cannot update", trader, "PUT", "/api/sales/crypto/transactions/1", forbidden},
+		{"trader cannot delete", trader, "DELETE", "/api/sales/crypto/transactions/1", forbidden},
+		{"trader cannot import", trader, "POST", "/api/sales/crypto/transactions/import", forbidden},
+		{"admin updates", admin, "PUT", "/api/sales/crypto/transactions/1", allowed},
+		{"admin deletes", admin, "DELETE", "/api/sales/crypto/transactions/1", allowed},
+		{"admin imports", admin, "POST", "/api/sales/crypto/transactions/import", allowed},
+		{"trader gets history", trader, "GET", "/api/sales/crypto/transactions/1/history", allowed},
+		{"trader cannot list the audit trail", trader, "GET", "/api/sales/audit", forbidden},
+		{"admin lists the audit trail", admin, "GET", "/api/sales/audit", allowed},
+		{"unknown routes are left to the router", viewer, "GET", "/api/sales/crypto/unknown", allowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, tt.method, tt.path, tt.key, nil)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.want != forbidden {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("expected a problem response, got %q", got)
+			}
+			var problem apierror.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("failed to decode the problem: %v", err)
+			}
+			if problem.Type != "about:blank" || problem.Title != "Forbidden" ||
+				problem.Status != forbidden || problem.Detail == "" || problem.Code != "FORBIDDEN" {
+				t.Errorf("unexpected problem %+v", problem)
+			}
+		})
+	}
+}
+
+// Every database route must be reachable by some built-in role, otherwise
+// new endpoints are silently unusable once auth is enabled.
+func TestPolicy_CoversEveryRoute(t *testing.T) {
+	app, _ := newAuthTestApp(t)
+	router := app.mount().(chi.Routes)
+
+	superuser := &auth.Principal{Roles: []string{"viewer", "trader", "admin"}}
+
+	const prefix = "/api/{database_id}"
+	routes := 0
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		pattern, ok := strings.CutPrefix(route, prefix)
+		if !ok {
+			return nil
+		}
+		routes++
+		if !app.policy.Allows(superuser, "sales", method, pattern) {
+			t.Errorf("no built-in role permits %s %s", method, pattern)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if routes == 0 {
+		t.Fatal("expected database routes in the router")
+	}
+}
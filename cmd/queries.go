@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// Statements listed per ranking by the queries endpoint, by default and at most
+const (
+	defaultTopQueries = 10
+	maxTopQueries     = 100
+)
+
+// queryStats returns the statement statistics of a database, creating them
+// on first use.
+func (app *application) queryStats(dbID string) *repo.QueryStats {
+	app.queryStatsMutex.Lock()
+	defer app.queryStatsMutex.Unlock()
+
+	stats, exists := app.queries[dbID]
+	if !exists {
+		stats = repo.NewQueryStats(dbID, app.slowQueryThreshold)
+		app.queries[dbID] = stats
+	}
+	return stats
+}
+
+// removeQueryStats drops the statistics of a removed database.
+func (app *application) removeQueryStats(dbID string) {
+	app.queryStatsMutex.Lock()
+	defer app.queryStatsMutex.Unlock()
+	delete(app.queries, dbID)
+}
+
+// slowQueryThreshold returns the current slow query threshold, so that a
+// reload applies to the running statistics.
+func (app *application) slowQueryThreshold() time.Duration {
+	app.cfgMutex.RLock()
+	defer app.cfgMutex.RUnlock()
+	return app.cfg.QueryLog.SlowThreshold
+}
+
+// Top statements of a database by total and by p95 time - used to find the
+// queries worth tuning
+func (app *application) databaseQueriesHandler(w http.ResponseWriter, r *http.Request) {
+	type QueryStats struct {
+		Fingerprint string  `json:"fingerprint"`
+		Statement   string  `json:"statement"`
+		Calls       int64   `json:"calls"`
+		Errors      int64   `json:"errors"`
+		Rows        int64   `json:"rows"`
+		TotalMs     float64 `json:"total_ms"`
+		MeanMs      float64 `json:"mean_ms"`
+		P95Ms       float64 `json:"p95_ms"`
+		MaxMs       float64 `json:"max_ms"`
+	}
+
+	dbID := strings.ToLower(chi.URLParam(r, "database_id"))
+	if _, exists := app.databaseConfig(dbID); !exists {
+		writeDatabaseNotFound(w)
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultTopQueries
+	}
+	limit = min(limit, maxTopQueries)
+
+	toJSON := func(statements []repo.StatementStats) []QueryStats {
+		out := make([]QueryStats, 0, len(statements))
+		for _, s := range statements {
+			out = append(out, QueryStats{
+				Fingerprint: s.Fingerprint,
+				Statement:   s.Statement,
+				Calls:       s.Calls,
+				Errors:      s.Errors,
+				Rows:        s.Rows,
+				TotalMs:     milliseconds(s.Total),
+				MeanMs:      milliseconds(s.Mean),
+				P95Ms:       milliseconds(s.P95),
+				MaxMs:       milliseconds(s.Max),
+			})
+		}
+		return out
+	}
+
+	statements := app.queryStats(dbID).Statements()
+	byTotal := repo.TopStatements(statements, limit, func(s repo.StatementStats) time.Duration { return s.Total })
+	byP95 := repo.TopStatements(statements, limit, func(s repo.StatementStats) time.Duration { return s.P95 })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"database_id":       dbID,
+		"statements":        len(statements),
+		"slow_threshold_ms": milliseconds(app.slowQueryThreshold()),
+		"by_total":          toJSON(byTotal),
+		"by_p95":            toJSON(byP95),
+	})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+func TestDatabaseQueries(t *testing.T) {
+	captureLogs(t, nil)
+	app := newTestApp(map[string]config.DatabaseConfig{
+		"sales": {Host: "127.0.0.1", Port: 1, SID: "SALES", User: "u", Password: "p"},
+	})
+	app.cfg.QueryLog.SlowThreshold = 250 * time.Millisecond
+	router := app.mount()
+
+	if w := serve(router, "GET", "/admin/databases/nope/queries", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown database, got %d", w.Code)
+	}
+
+	w := serve(router, "GET", "/admin/databases/SALES/queries?limit=5", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var body struct {
+		DatabaseID      string            `json:"database_id"`
+		Statements      int               `json:"statements"`
+		SlowThresholdMs float64           `json:"slow_threshold_ms"`
+		ByTotal         []json.RawMessage `json:"by_total"`
+		ByP95           []json.RawMessage `json:"by_p95"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if body.DatabaseID != "sales" || body.Statements != 0 || body.SlowThresholdMs != 250 {
+		t.Errorf("unexpected response %+v", body)
+	}
+	if body.ByTotal == nil || body.ByP95 == nil {
+		t.Error("expected empty rankings rather than null")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+func TestRateLimits_AppliedAndReloaded(t *testing.T) {
+	captureLogs(t, nil)
+	app := newTestApp(map[string]config.DatabaseConfig{
+		"sales": {Host: "127.0.0.1", Port: 1, SID: "SALES", User: "u", Password: "p"},
+	})
+	app.cfg.RateLimits = map[string]config.RateLimitConfig{
+		"api": {PerClient: config.LimitConfig{Rate: 0.1, Burst: 1}},
+	}
+	router := app.mount()
+
+	// The first request reaches the (unreachable) database, the second is
+	// rejected before a connection is attempted
+	if w := serve(router, "GET", "/api/sales/crypto/transactions", "", nil); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	w := serve(router, "GET", "/api/sales/crypto/transactions", "", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	// Other route groups are not limited
+	for i := 0; i < 3; i++ {
+		if w := serve(router, "GET", "/health", "", nil); w.Code != http.StatusOK {
+			t.Fatalf("expected /health to be unlimited, got %d", w.Code)
+		}
+	}
+
+	// Reloaded limits apply to the running router
+	app.cfgMutex.Lock()
+	app.cfg.RateLimits = nil
+	app.cfgMutex.Unlock()
+	if w := serve(router, "GET", "/api/sales/crypto/transactions", "", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected the limit to be lifted after reload, got %d", w.Code)
+	}
+}
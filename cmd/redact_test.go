@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/admission"
+	"github.com/hotbrandon/go-chi/internal/config"
+	"github.com/hotbrandon/go-chi/internal/metrics"
+	"github.com/hotbrandon/go-chi/internal/ratelimit"
+	"github.com/hotbrandon/go-chi/internal/redact"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// newTestApp returns an application configured with the given databases,
+// none of them connected yet
+func newTestApp(databases map[string]config.DatabaseConfig) *application {
+	for id, db := range databases {
+		db.ID = id
+		if db.Pool == (config.PoolConfig{}) {
+			db.Pool = config.DefaultPool
+		}
+		if db.Admission == (config.AdmissionConfig{}) {
+			db.Admission = config.AdmissionConfig{MaxInFlight: 5, MaxQueue: 10, MaxWait: time.Second}
+		}
+		if db.Mode.Name == "" {
+			db.Mode.Name = config.ModeNormal
+		}
+		databases[id] = db
+	}
+	app := &application{
+		cfg: &config.Config{
+			Server:    config.ServerConfig{RequestTimeout: 10 * time.Second},
+			AccessLog: config.AccessLogConfig{SampleRate: 1, SlowThreshold: time.Second},
+			Databases: databases,
+		},
+		redactor:   redact.New(),
+		rateLimits: ratelimit.NewMemoryStore(),
+		admission:  make(map[string]*admission.Controller),
+		queries:    make(map[string]*repo.QueryStats),
+		dbs:        make(map[string]*sql.DB),
+		servers:    make(map[string]serverInfo),
+		replicas:   make(map[string]*replicaSet),
+		modes:      make(map[string]config.ModeConfig),
+		failedDBs:  make(map[string]time.Time),
+		pings:      make(map[string]pingRecord),
+	}
+	app.started.Store(true)
+	app.metrics = metrics.New(app.connectedDBs, app.admissionStats)
+	return app
+}
+
+// captureLogs routes the default logger through a redacting handler into a
+// buffer for the duration of the test
+func captureLogs(t *testing.T, redactor *redact.Redactor) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(redact.NewHandler(slog.NewJSONHandler(&buf, nil), redactor)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestPasswordsNeverLeak(t *testing.T) {
+	const password = "Sup3rS3cret!"
+
+	app := newTestApp(map[string]config.DatabaseConfig{
+		// Nothing listens on port 1, so connecting fails fast
+		"sales": {Host: "127.0.0.1", Port: 1, SID: "SALES", User: "app_user", Password: password},
+		"hr":    {DSN: "oracle://hr_user:" + password + "@127.0.0.1:1/HR"},
+	})
+	app.redactor.AddSecrets(app.cfg.SecretValues()...)
+	logs := captureLogs(t, app.redactor)
+
+	router := app.mount()
+
+	for _, dbID := range []string{"sales", "hr"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/"+dbID+"/crypto/transactions", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status %d, got %d", dbID, http.StatusServiceUnavailable, w.Code)
+		}
+		if strings.Contains(w.Body.String(), password) {
+			t.Errorf("%s: response body contains the password: %s", dbID, w.Body.String())
+		}
+	}
+
+	// Log the config values directly too, as a careless caller might
+	dbConfig := app.cfg.Databases["hr"]
+	slog.Info("debug", "config", dbConfig, "dsn", dbConfig.BuildDSN())
+	slog.Error("wrapped", "error", fmt.Errorf("open %s failed", dbConfig.BuildDSN()))
+
+	if logs.Len() == 0 {
+		t.Fatal("expected connection failures to be logged")
+	}
+	if strings.Contains(logs.String(), password) {
+		t.Errorf("log output contains the password:\n%s", logs.String())
+	}
+}
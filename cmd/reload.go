@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+)
+
+// How often the config file is checked for modifications.
+const configPollInterval = 5 * time.Second
+
+// watchConfig reloads the configuration on SIGHUP and, when a config file is
+// in use, whenever its modification time changes. Polling is used instead of
+// inotify so that Kubernetes ConfigMap symlink swaps are picked up too.
+func (app *application) watchConfig(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	var lastMod time.Time
+	if app.configPath != "" {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		lastMod = fileModTime(app.configPath)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("received SIGHUP, reloading configuration")
+			app.reloadConfig()
+		case <-tick:
+			modTime := fileModTime(app.configPath)
+			if modTime.IsZero() || modTime.Equal(lastMod) {
+				continue
+			}
+			lastMod = modTime
+			slog.Info("config file changed, reloading configuration",
+				"path", app.configPath)
+			app.reloadConfig()
+		}
+	}
+}
+
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reloadConfig re-reads the configuration and applies the difference:
+// removed databases are closed, added ones dialled, ones whose connection
+// settings changed are re-dialled, and pool and admission changes are
+// applied in place. An invalid configuration is logged and the current one
+// kept, as is the current configuration of a database that fails to load.
+func (app *application) reloadConfig() error {
+	cfg, warnings, err := loadConfig(app.configPath, app.secrets)
+	if err != nil {
+		slog.Error("config reload failed, keeping current configuration",
+			"error", err)
+		return err
+	}
+	app.redactor.AddSecrets(cfg.SecretValues()...)
+
+	app.cfgMutex.Lock()
+	old := app.cfg
+	for _, warning := range warnings {
+		// An unreachable secret provider must not close a serving pool
+		var skipped *config.SkippedDatabase
+		if !errors.As(warning, &skipped) {
+			continue
+		}
+		if current, exists := old.Databases[skipped.ID]; exists {
+			slog.Warn("database configuration failed to reload, keeping the current one",
+				"database_id", skipped.ID,
+				"error", warning)
+			cfg.Databases[skipped.ID] = current
+		}
+	}
+	if cfg.Server != old.Server {
+		// The listener and middleware stack are built once at startup
+		slog.Warn("server settings changed, restart required to apply them")
+		cfg.Server = old.Server
+	}
+	if !reflect.DeepEqual(cfg.Auth, old.Auth) {
+		// Authenticators are built once at startup
+		slog.Warn("auth settings changed, restart required to apply them")
+		cfg.Auth = old.Auth
+	}
+	if cfg.Tracing != old.Tracing {
+		// The tracer provider is installed once at startup
+		slog.Warn("tracing settings changed, restart required to apply them")
+		cfg.Tracing = old.Tracing
+	}
+	app.cfg = cfg
+	app.cfgMutex.Unlock()
+
+	changes := config.DiffDatabases(old.Databases, cfg.Databases)
+	if changes.Empty() {
+		slog.Info("configuration reloaded, no database changes")
+		return nil
+	}
+
+	for _, dbID := range changes.Removed {
+		app.closeDatabase(dbID)
+		app.removeAdmission(dbID)
+		app.removeQueryStats(dbID)
+		app.removeMode(dbID)
+		app.removePings(dbID)
+	}
+
+	for _, dbID := range changes.Changed {
+		app.retuneAdmission(dbID, cfg.Databases[dbID].Admission)
+		app.closeReplicas(dbID)
+
+		// connectDatabase swaps the pool only once the new one is verified;
+		// if that fails, the old pool points at stale settings, so drop it.
+		if _, err := app.connectDatabase(dbID); err != nil {
+			app.closeDatabase(dbID)
+			app.markFailed(dbID)
+		}
+	}
+
+	for _, dbID := range changes.Added {
+		app.connectDatabase(dbID)
+	}
+
+	for _, dbID := range changes.Retuned {
+		app.dbMutex.RLock()
+		db, exists := app.dbs[dbID]
+		app.dbMutex.RUnlock()
+		if exists {
+			applyPoolConfig(db, cfg.Databases[dbID].Pool)
+		}
+		app.retuneReplicas(dbID, cfg.Databases[dbID].Pool)
+		app.retuneAdmission(dbID, cfg.Databases[dbID].Admission)
+	}
+
+	slog.Info("configuration reloaded",
+		"added", changes.Added,
+		"removed", changes.Removed,
+		"redialed", changes.Changed,
+		"retuned", changes.Retuned)
+	return nil
+}
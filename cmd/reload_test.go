@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/secrets"
+)
+
+func TestReloadConfig_KeepsDatabaseWhoseSecretFails(t *testing.T) {
+	captureLogs(t, nil)
+	dir := t.TempDir()
+	dsnFile := filepath.Join(dir, "sales.dsn")
+	if err := os.WriteFile(dsnFile, []byte(sqliteDatabase(t, "sales").DSN), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	contents := "databases:\n  sales:\n    driver: sqlite\n    dsn_ref: file:" + dsnFile + "\n"
+	if err := os.WriteFile(configPath, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Arrange: sales is serving, then its secret becomes unreadable
+	resolver := secrets.NewResolver()
+	cfg, _, err := loadConfig(configPath, resolver)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	app := newTestApp(cfg.Databases)
+	app.cfg, app.configPath, app.secrets = cfg, configPath, resolver
+	db, err := app.connectDatabase("sales")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	if err := os.Remove(dsnFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	if err := app.reloadConfig(); err != nil {
+		t.Fatalf("unexpected reload error: %v", err)
+	}
+
+	// Assert
+	if _, exists := app.databaseConfig("sales"); !exists {
+		t.Error("expected sales to stay configured")
+	}
+	if got := app.connectedDBs()["sales"]; got != db {
+		t.Fatal("expected the sales pool to be kept")
+	}
+	if err := db.Ping(); err != nil {
+		t.Errorf("expected the sales pool to stay open, got %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+	"github.com/hotbrandon/go-chi/internal/repo"
+	"github.com/hotbrandon/go-chi/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// How long the health of a replica is trusted before it is checked again.
+// A replica that failed is not retried sooner either.
+const replicaCheckInterval = 5 * time.Second
+
+// Request header with which a client forces its reads onto the primary
+const readConsistencyHeader = "X-Read-Consistency"
+
+var (
+	errNoReplica       = errors.New("no read replica is usable")
+	errReplicaChecking = errors.New("replica is being checked")
+	errReplicaClosed   = errors.New("replica was closed")
+)
+
+// replicaSet holds the replicas of one database, in configuration order
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64 // Spreads reads over the replicas
+}
+
+// replica is the pool of one read replica and its last health check. mu
+// is never held during a check, which may take seconds.
+type replica struct {
+	mu       sync.Mutex
+	db       *sql.DB // nil until connected
+	checked  time.Time
+	err      error // Why the replica is unusable, or nil
+	checking bool  // A check is running; others use the last outcome
+	closed   bool
+}
+
+// replicaReads reports whether the reads of r may go to a replica: only
+// those of GET requests, so that a write never reads stale data, and only
+// if the client did not ask for strong consistency.
+func replicaReads(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	return !strings.EqualFold(r.Header.Get(readConsistencyHeader), "strong")
+}
+
+// repository returns the repository for a request to dbID, whose writes
+// run on db. With replicaReads its reads go to a usable replica of dbID,
+// or, failing that, as its reads fallback says.
+func (app *application) repository(ctx context.Context, dbID string, db *sql.DB, dbConfig config.DatabaseConfig, replicaReads bool) (*repo.Repository, error) {
+	stats := app.queryStats(dbID)
+	repository := repo.New(repo.Instrument(db, stats), app.databaseDialect(dbID, dbConfig))
+	if !replicaReads || len(dbConfig.Replicas) == 0 {
+		return repository, nil
+	}
+
+	replica, err := app.readReplica(ctx, dbID, dbConfig)
+	if err != nil {
+		if dbConfig.Reads.Fallback == config.FallbackFail {
+			return nil, err
+		}
+		return repository, nil
+	}
+	return repository.WithReader(repo.Instrument(replica, stats)), nil
+}
+
+// readReplica returns a usable replica of dbID, trying each in turn from
+// the one after the last used.
+func (app *application) readReplica(ctx context.Context, dbID string, dbConfig config.DatabaseConfig) (*sql.DB, error) {
+	app.dbMutex.Lock()
+	set, exists := app.replicas[dbID]
+	if !exists {
+		set = &replicaSet{replicas: make([]*replica, len(dbConfig.Replicas))}
+		for i := range set.replicas {
+			set.replicas[i] = &replica{}
+		}
+		app.replicas[dbID] = set
+	}
+	app.dbMutex.Unlock()
+
+	// A reload may have changed the replicas since the set was made
+	count := min(len(set.replicas), len(dbConfig.Replicas))
+	start := int(set.next.Add(1) % uint64(max(count, 1)))
+	var errs []error
+	for i := range count {
+		n := (start + i) % count
+		db, err := app.checkReplica(ctx, set.replicas[n], dbID, n, dbConfig)
+		if err == nil {
+			return db, nil
+		}
+		errs = append(errs, fmt.Errorf("replica %d: %w", n, err))
+	}
+	return nil, fmt.Errorf("%w: %w", errNoReplica, errors.Join(errs...))
+}
+
+// checkReplica returns the pool of replica n of dbID, connecting it first
+// if needed, unless it is down or too far behind. The outcome is cached
+// for replicaCheckInterval, and used by other requests while one of them
+// checks the replica again.
+func (app *application) checkReplica(ctx context.Context, r *replica, dbID string, n int, dbConfig config.DatabaseConfig) (*sql.DB, error) {
+	r.mu.Lock()
+	switch {
+	case r.closed:
+		r.mu.Unlock()
+		return nil, errReplicaClosed
+	case r.checking && r.checked.IsZero():
+		r.mu.Unlock()
+		return nil, errReplicaChecking
+	case r.checking || time.Since(r.checked) < replicaCheckInterval:
+		defer r.mu.Unlock()
+		return r.db, r.err
+	}
+	r.checking = true
+	current := r.db
+	r.mu.Unlock()
+
+	ctx, span := tracer.Start(context.WithoutCancel(ctx), "db.replica.check",
+		trace.WithAttributes(tracing.DatabaseIDKey.String(dbID)))
+	db, err := app.probeReplica(ctx, current, dbID, n, dbConfig)
+	app.endSpan(span, err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checking = false
+	if r.closed {
+		// closeReplicas closed current; the pool opened here is ours
+		if db != nil && db != current {
+			db.Close()
+		}
+		return nil, errReplicaClosed
+	}
+	r.db = db
+
+	switch {
+	case err != nil && r.err == nil:
+		slog.Warn("read replica is unusable, reading elsewhere",
+			"database_id", dbID,
+			"replica", n,
+			"error", err)
+	case err == nil && r.err != nil:
+		slog.Info("read replica is usable again",
+			"database_id", dbID,
+			"replica", n)
+	}
+	r.checked, r.err = time.Now(), err
+	return r.db, err
+}
+
+// probeReplica connects replica n of dbID, or pings db, its open pool, and
+// checks its lag against the database's max_staleness. It returns the pool
+// to keep, nil if none could be opened.
+func (app *application) probeReplica(ctx context.Context, db *sql.DB, dbID string, n int, dbConfig config.DatabaseConfig) (*sql.DB, error) {
+	if db == nil {
+		opened, err := openDatabase(dialect(dbConfig).DriverName(), config.DSN(dbConfig.Replicas[n].DSN),
+			fmt.Sprintf("%s replica %d", dbID, n), dbConfig.Pool)
+		if err != nil {
+			return nil, err
+		}
+		db = opened
+	} else {
+		pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		if err := db.PingContext(pingCtx); err != nil {
+			return db, err
+		}
+	}
+	return db, checkStaleness(ctx, db, app.databaseDialect(dbID, dbConfig), dbConfig.Reads.MaxStaleness)
+}
+
+// checkStaleness fails if the replica db lags more than maxStaleness behind
+// its primary; 0 accepts any lag.
+func checkStaleness(ctx context.Context, db *sql.DB, d repo.Dialect, maxStaleness time.Duration) error {
+	if maxStaleness == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	lag, err := repo.ReplicaLag(ctx, db, d)
+	if err != nil {
+		return err
+	}
+	if lag > maxStaleness {
+		return fmt.Errorf("%v behind the primary, over max_staleness (%v)", lag.Round(time.Millisecond), maxStaleness)
+	}
+	return nil
+}
+
+// retuneReplicas applies the pool settings of dbID to its open replicas.
+func (app *application) retuneReplicas(dbID string, pool config.PoolConfig) {
+	app.dbMutex.RLock()
+	set := app.replicas[dbID]
+	app.dbMutex.RUnlock()
+	if set == nil {
+		return
+	}
+	for _, r := range set.replicas {
+		r.mu.Lock()
+		if r.db != nil {
+			applyPoolConfig(r.db, pool)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// closeReplicas closes and forgets the replica pools of dbID, which are
+// reopened on the next read.
+func (app *application) closeReplicas(dbID string) {
+	app.dbMutex.Lock()
+	set := app.replicas[dbID]
+	delete(app.replicas, dbID)
+	app.dbMutex.Unlock()
+	if set != nil {
+		set.close()
+	}
+}
+
+func (s *replicaSet) close() {
+	for _, r := range s.replicas {
+		r.mu.Lock()
+		if r.db != nil {
+			r.db.Close()
+			r.db = nil
+		}
+		r.closed = true
+		r.mu.Unlock()
+	}
+}
+
+// openCLIReplica opens the first usable replica of dbID for a command that
+// only reads. It returns nil when dbID has none and may fall back to the
+// primary.
+func openCLIReplica(cfg *config.Config, dbID string, d repo.Dialect) (*sql.DB, error) {
+	dbConfig := cfg.Databases[dbID]
+	var errs []error
+	for n, replica := range dbConfig.Replicas {
+		db, err := openDatabase(d.DriverName(), config.DSN(replica.DSN), fmt.Sprintf("%s replica %d", dbID, n),
+			config.PoolConfig{MaxOpenConns: 2, MaxIdleConns: config.IdleConns(1)})
+		if err == nil {
+			if err = checkStaleness(context.Background(), db, d, dbConfig.Reads.MaxStaleness); err != nil {
+				db.Close()
+			}
+		}
+		if err == nil {
+			return db, nil
+		}
+		errs = append(errs, fmt.Errorf("replica %d: %w", n, err))
+	}
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	err := fmt.Errorf("%w: %w", errNoReplica, errors.Join(errs...))
+	if dbConfig.Reads.Fallback == config.FallbackFail {
+		return nil, err
+	}
+	slog.Warn("reading from the primary", "database_id", dbID, "error", err)
+	return nil, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// seedReplica migrates the SQLite database at dsn and inserts transactions,
+// keeping it open (and so alive, if in memory) until the test ends
+func seedReplica(t *testing.T, dsn string, transactions ...repo.Transaction) {
+	t.Helper()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := newMigrator("replica", db, repo.SQLite, time.Second)
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("failed to migrate replica: %v", err)
+	}
+	for _, tx := range transactions {
+		if err := repo.New(db, repo.SQLite).CreateTransaction(context.Background(), tx); err != nil {
+			t.Fatalf("failed to seed replica: %v", err)
+		}
+	}
+}
+
+// newReplicaTestApp returns an app whose sales database has one BTC
+// transaction, and a replica with only an ETH one, so that the holdings
+// tell which was read
+func newReplicaTestApp(t *testing.T, replicas []config.ReplicaConfig, reads config.ReadsConfig) *application {
+	t.Helper()
+	captureLogs(t, nil)
+	sales := sqliteDatabase(t, "sales")
+	sales.Replicas = replicas
+	sales.Reads = reads
+	app := newTestApp(map[string]config.DatabaseConfig{"sales": sales})
+	seedDatabase(t, app, "sales", buy("BTC", 1, 100, "2024-01-10"))
+	return app
+}
+
+func readHoldings(t *testing.T, router http.Handler, consistency string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/sales/crypto/holdings", nil)
+	if consistency != "" {
+		req.Header.Set(readConsistencyHeader, consistency)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return w.Code, ""
+	}
+	response := decodeFanOut(t, w.Body)
+	if len(response.Holdings) != 1 {
+		t.Fatalf("expected one holding, got %+v", response.Holdings)
+	}
+	return w.Code, response.Holdings[0].CoinSymbol
+}
+
+// ============================================================================
+// Read Replica Tests
+// ============================================================================
+
+func TestReplicas_ReadsGoToReplica(t *testing.T) {
+	// Arrange
+	replica := sqliteDatabase(t, "replica")
+	app := newReplicaTestApp(t, []config.ReplicaConfig{{DSN: replica.DSN}},
+		config.ReadsConfig{Fallback: config.FallbackPrimary})
+	seedReplica(t, replica.DSN, buy("ETH", 2, 20, "2024-03-01"))
+	router := app.mount()
+
+	// Act
+	_, replicated := readHoldings(t, router, "")
+	_, strong := readHoldings(t, router, "Strong")
+	write := serve(router, "POST", "/api/sales/crypto/transactions", "", buy("BTC", 1, 100, "2024-04-01"))
+
+	// Assert
+	if replicated != "ETH" {
+		t.Errorf("expected reads from the replica, got %s", replicated)
+	}
+	if strong != "BTC" {
+		t.Errorf("expected strong reads from the primary, got %s", strong)
+	}
+	if write.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", write.Code, write.Body.String())
+	}
+	transactions, err := repo.New(app.connectedDBs()["sales"], repo.SQLite).ListTransactions(context.Background(), 1, 10)
+	if err != nil || len(transactions) != 2 {
+		t.Errorf("expected the write on the primary, got %+v (%v)", transactions, err)
+	}
+}
+
+func TestReplicas_Fallback(t *testing.T) {
+	unreachable := []config.ReplicaConfig{{DSN: "file:/nonexistent/replica.db?mode=ro"}}
+
+	tests := []struct {
+		fallback string
+		wantCode int
+		wantCoin string
+	}{
+		{config.FallbackPrimary, http.StatusOK, "BTC"},
+		{config.FallbackFail, http.StatusServiceUnavailable, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fallback, func(t *testing.T) {
+			// Arrange
+			app := newReplicaTestApp(t, unreachable, config.ReadsConfig{Fallback: tt.fallback})
+			router := app.mount()
+
+			// Act
+			code, coin := readHoldings(t, router, "")
+			strongCode, _ := readHoldings(t, router, "strong")
+
+			// Assert
+			if code != tt.wantCode || coin != tt.wantCoin {
+				t.Errorf("expected %d %q, got %d %q", tt.wantCode, tt.wantCoin, code, coin)
+			}
+			if strongCode != http.StatusOK {
+				t.Errorf("expected strong reads to be served by the primary, got %d", strongCode)
+			}
+		})
+	}
+}
+
+func TestReplicas_CheckDoesNotBlock(t *testing.T) {
+	// Arrange: the replica is being checked by another request
+	replicaDB := sqliteDatabase(t, "replica")
+	app := newReplicaTestApp(t, []config.ReplicaConfig{{DSN: replicaDB.DSN}},
+		config.ReadsConfig{Fallback: config.FallbackFail})
+	dbConfig, _ := app.databaseConfig("sales")
+	pool, err := sql.Open("sqlite", replicaDB.DSN)
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	r := &replica{db: pool, checked: time.Now().Add(-time.Hour), checking: true}
+	app.replicas["sales"] = &replicaSet{replicas: []*replica{r}}
+
+	// Act
+	db, err := app.checkReplica(context.Background(), r, "sales", 0, dbConfig)
+	app.retuneReplicas("sales", dbConfig.Pool)
+	app.closeReplicas("sales")
+	_, closedErr := app.checkReplica(context.Background(), r, "sales", 0, dbConfig)
+
+	// Assert: the last outcome is used, and the pool closed, without waiting
+	if db != pool || err != nil {
+		t.Errorf("expected the cached replica, got %v (%v)", db, err)
+	}
+	if err := pool.Ping(); err == nil {
+		t.Error("expected the replica pool to be closed")
+	}
+	if !errors.Is(closedErr, errReplicaClosed) {
+		t.Errorf("expected %v after close, got %v", errReplicaClosed, closedErr)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+)
+
+// Header naming the databases a request was routed to
+const targetHeader = "X-Database-Target"
+
+type groupMembersKey struct{}
+
+// resolveDatabase resolves the {database_id} of a request. Aliases are
+// replaced by the database they stand for, so everything after sees the
+// canonical id; groups are handed to groups, which fans out over their
+// members. Unknown names pass through to be rejected downstream.
+func (app *application) resolveDatabase(groups http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := chi.RouteContext(r.Context())
+			app.cfgMutex.RLock()
+			target, exists := app.cfg.Resolve(rctx.URLParam("database_id"))
+			app.cfgMutex.RUnlock()
+			if !exists {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set(targetHeader, strings.Join(target.IDs, ","))
+			if target.Group {
+				ctx := context.WithValue(r.Context(), groupMembersKey{}, target.IDs)
+				groups.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			for i, key := range rctx.URLParams.Keys {
+				if key == "database_id" {
+					rctx.URLParams.Values[i] = target.IDs[0]
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// groupMembers returns the databases of the group a request was routed to
+func groupMembers(ctx context.Context) ([]string, bool) {
+	ids, ok := ctx.Value(groupMembersKey{}).([]string)
+	return ids, ok
+}
+
+func writeGroupUnsupported(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, http.StatusBadRequest,
+		"Group Not Supported",
+		"Groups of databases only answer the read endpoints of /api/_all.",
+		"GROUP_UNSUPPORTED")
+}
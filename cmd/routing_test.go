@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// newRoutingTestApp is newFanOutTestApp with an alias and a group
+func newRoutingTestApp(t *testing.T) *application {
+	t.Helper()
+	app := newFanOutTestApp(t)
+	app.cfg.Aliases = map[string]string{"revenue": "sales"}
+	app.cfg.Groups = map[string][]string{"books": {"finance", "sales"}}
+	return app
+}
+
+// ============================================================================
+// Routing Tests
+// ============================================================================
+
+func TestRouting_Alias(t *testing.T) {
+	// Arrange
+	app := newRoutingTestApp(t)
+	router := app.mount()
+
+	// Act
+	w := serve(router, "GET", "/api/Revenue/crypto/holdings", "", nil)
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(targetHeader); got != "sales" {
+		t.Errorf("expected the alias to resolve to sales, got %q", got)
+	}
+	if response := decodeFanOut(t, w.Body); len(response.Holdings) != 2 {
+		t.Errorf("expected the holdings of sales, got %+v", response.Holdings)
+	}
+}
+
+func TestRouting_Group(t *testing.T) {
+	// Arrange
+	app := newRoutingTestApp(t)
+	router := app.mount()
+
+	// Act
+	w := serve(router, "GET", "/api/books/crypto/holdings", "", nil)
+	unsupported := serve(router, "POST", "/api/books/crypto/transactions", "", buy("BTC", 1, 100, "2024-04-01"))
+
+	// Assert: hr, which is not in the group, is not queried
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(targetHeader); got != "finance,sales" {
+		t.Errorf("expected the group members as target, got %q", got)
+	}
+	response := decodeFanOut(t, w.Body)
+	if response.Partial || len(response.Databases) != 2 {
+		t.Errorf("expected finance and sales only, got %+v", response.Databases)
+	}
+	if len(response.Holdings) != 2 || response.Holdings[0].Quantity != 1.5 {
+		t.Errorf("expected holdings summed over the group, got %+v", response.Holdings)
+	}
+
+	if unsupported.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for writes to a group, got %d", unsupported.Code)
+	}
+}
+
+func TestRouting_UnknownName(t *testing.T) {
+	app := newRoutingTestApp(t)
+
+	w := serve(app.mount(), "GET", "/api/payroll/crypto/holdings", "", nil)
+
+	if w.Code != http.StatusNotFound || w.Header().Get(targetHeader) != "" {
+		t.Errorf("expected 404 without a target, got %d %q", w.Code, w.Header().Get(targetHeader))
+	}
+}
+
+func TestRouting_ListDatabases(t *testing.T) {
+	app := newRoutingTestApp(t)
+
+	w := serve(app.mount(), "GET", "/databases", "", nil)
+
+	var response struct {
+		Aliases map[string]string   `json:"aliases"`
+		Groups  map[string][]string `json:"groups"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Aliases["revenue"] != "sales" || len(response.Groups["books"]) != 2 {
+		t.Errorf("expected the alias and group to be listed, got %+v", response)
+	}
+}
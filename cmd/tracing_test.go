@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing_ReconnectSpanIsRedacted(t *testing.T) {
+	const password = "Sup3rS3cret!"
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	captureLogs(t, nil)
+	app := newTestApp(map[string]config.DatabaseConfig{
+		// Nothing listens on port 1, so connecting fails fast
+		"sales": {DSN: "oracle://app_user:" + password + "@127.0.0.1:1/SALES"},
+	})
+	app.redactor.AddSecrets(app.cfg.SecretValues()...)
+
+	if w := serve(app.mount(), "GET", "/api/sales/crypto/transactions", "", nil); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	server, ok := spans["GET /api/{database_id}/*"]
+	if !ok {
+		t.Fatalf("expected a server span, got %v", spans)
+	}
+	reconnect, ok := spans["db.reconnect"]
+	if !ok {
+		t.Fatalf("expected a reconnect span, got %v", spans)
+	}
+	if reconnect.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("expected the reconnect span to be a child of the request span")
+	}
+
+	if strings.Contains(reconnect.Status().Description, password) {
+		t.Errorf("reconnect span status contains the password: %s", reconnect.Status().Description)
+	}
+	for _, event := range reconnect.Events() {
+		for _, attr := range event.Attributes {
+			if strings.Contains(attr.Value.Emit(), password) {
+				t.Errorf("reconnect span event contains the password: %s", attr.Value.Emit())
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// Transactions read per query by export
+const exportPageSize = 500
+
+// runImport loads a CSV file (see repo.ReadTransactionsCSV) into one
+// database. Unlike the HTTP import, rows are inserted in one transaction,
+// so a failure leaves the table as it was.
+func runImport(c *cli, args []string) error {
+	flags, configPath := c.flagSet("import", "")
+	dbID := flags.String("database", "", "database id to import into (required)")
+	file := flags.String("file", "-", "CSV file to read, or - for stdin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbID == "" {
+		flags.Usage()
+		return errors.New("-database is required")
+	}
+
+	cfg, _, err := c.loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	ids, err := selectDatabases(cfg, *dbID, nil)
+	if err != nil {
+		return err
+	}
+
+	in := c.in
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	transactions, err := repo.ReadTransactionsCSV(in)
+	if err != nil {
+		return err
+	}
+	for i, t := range transactions {
+		if _, err := time.Parse(time.DateOnly, t.TransactionDate); err != nil {
+			return fmt.Errorf("row %d: invalid transaction_date format (expected YYYY-MM-DD)", i+1)
+		}
+	}
+
+	db, err := openCLIDatabase(cfg, ids[0])
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	server := detectServer(db, ids[0], cfg.Databases[ids[0]])
+	repository := repo.New(db, server.dialect).WithTx(tx).WithAudit(repo.Audit{
+		Actor:      cliActor(),
+		DatabaseID: ids[0],
+	})
+	for i, t := range transactions {
+		if err := repository.ImportTransaction(ctx, t); err != nil {
+			return fmt.Errorf("row %d: %w (nothing imported)", i+1, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "%s: imported %d transactions\n", ids[0], len(transactions))
+	return nil
+}
+
+// cliActor names the user running a command in the audit trail
+func cliActor() string {
+	if u, err := user.Current(); err == nil {
+		return "cli:" + u.Username
+	}
+	return "cli"
+}
+
+// runExport writes every transaction of one database as CSV, newest first,
+// in the format read by import.
+func runExport(c *cli, args []string) error {
+	flags, configPath := c.flagSet("export", "")
+	dbID := flags.String("database", "", "database id to export (required)")
+	file := flags.String("o", "-", "CSV file to write, or - for stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbID == "" {
+		flags.Usage()
+		return errors.New("-database is required")
+	}
+
+	cfg, _, err := c.loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	ids, err := selectDatabases(cfg, *dbID, nil)
+	if err != nil {
+		return err
+	}
+
+	db, err := openCLIDatabase(cfg, ids[0])
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	server := detectServer(db, ids[0], cfg.Databases[ids[0]])
+	repository := repo.New(db, server.dialect)
+	replica, err := openCLIReplica(cfg, ids[0], server.dialect)
+	if err != nil {
+		return err
+	}
+	if replica != nil {
+		defer replica.Close()
+		repository = repository.WithReader(replica)
+	}
+
+	transactions, err := exportTransactions(context.Background(), repository)
+	if err != nil {
+		return err
+	}
+
+	if *file == "-" {
+		err = repo.WriteTransactionsCSV(c.out, transactions)
+	} else {
+		err = writeFile(*file, func(w io.Writer) error { return repo.WriteTransactionsCSV(w, transactions) })
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Info("exported transactions", "database_id", ids[0], "rows", len(transactions))
+	return nil
+}
+
+// exportTransactions reads every transaction a page at a time
+func exportTransactions(ctx context.Context, repository *repo.Repository) ([]repo.Transaction, error) {
+	var all []repo.Transaction
+	for page := 1; ; page++ {
+		transactions, err := repository.ListTransactions(ctx, page, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, transactions...)
+		if len(transactions) < exportPageSize {
+			return all, nil
+		}
+	}
+}
+
+// writeFile creates path and writes it with write, reporting errors from
+// closing the file too
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
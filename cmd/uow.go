@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/auth"
+	"github.com/hotbrandon/go-chi/internal/handlers"
+	"github.com/hotbrandon/go-chi/internal/logging"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// Pause before running a request again after a serialization failure,
+// multiplied by the attempts so far
+const txRetryBackoff = 20 * time.Millisecond
+
+// unitOfWork runs a write request in one transaction: its statements are
+// committed together if it answers 2xx, and rolled back if it answers
+// anything else, panics or the client goes away. The response is held back
+// until the commit, so that a client is never told a lost write succeeded.
+// A request that fails to serialize with a concurrent one is run again, up
+// to transactions.max_attempts times. The changes it makes to transactions
+// are audited within the same transaction.
+func (app *application) unitOfWork(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repository, ok := handlers.GetRepo(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		primary, ok := repository.(*repo.Repository)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		dbID, _ := handlers.GetDBID(r.Context())
+		log := logging.FromContext(r.Context())
+
+		app.cfgMutex.RLock()
+		settings := app.cfg.Transactions
+		app.cfgMutex.RUnlock()
+
+		audit := repo.Audit{
+			Actor:      auditActor(r.Context()),
+			RequestID:  middleware.GetReqID(r.Context()),
+			DatabaseID: dbID,
+		}
+
+		// Each attempt reads the body afresh
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+
+		for attempt := 1; ; attempt++ {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			response, conflicted, err := runInTx(next, r, primary, audit, settings.IsolationLevel())
+			if r.Context().Err() != nil {
+				// Client gone or request timed out; the transaction was
+				// rolled back and nobody is left to answer
+				return
+			}
+
+			if conflicted && attempt < settings.MaxAttempts {
+				log.Warn("transaction failed to serialize, retrying",
+					"database_id", dbID,
+					"attempt", attempt)
+				select {
+				case <-time.After(time.Duration(attempt) * txRetryBackoff):
+				case <-r.Context().Done():
+					return
+				}
+				continue
+			}
+
+			switch {
+			case conflicted:
+				log.Warn("transaction failed to serialize, giving up",
+					"database_id", dbID,
+					"attempts", attempt)
+				apierror.Write(w, http.StatusConflict,
+					"Transaction Conflict",
+					"The request conflicted with concurrent changes. Please retry.",
+					"TX_CONFLICT")
+			case response == nil:
+				log.Warn("failed to begin transaction",
+					"database_id", dbID,
+					"error", err)
+				apierror.Write(w, http.StatusServiceUnavailable,
+					"Database Unavailable",
+					"The database is temporarily unavailable. Please try again later.",
+					"DB_UNAVAILABLE")
+			case err != nil:
+				log.Error("failed to commit transaction",
+					"database_id", dbID,
+					"error", err)
+				apierror.Write(w, http.StatusInternalServerError,
+					"Transaction Failed",
+					"The changes could not be committed and were rolled back.",
+					"TX_FAILED")
+			default:
+				response.flush(w)
+			}
+			return
+		}
+	})
+}
+
+// runInTx runs next once in a new transaction, auditing its changes, which
+// is committed if next answers 2xx and rolled back otherwise, and returns
+// the response held back. conflicted reports a serialization failure, in a
+// statement or the commit; the response is nil if the transaction could not
+// begin. What next deferred with handlers.OnCommit runs after a commit only.
+func runInTx(next http.Handler, r *http.Request, repository *repo.Repository, audit repo.Audit, isolation sql.IsolationLevel) (response *bufferedResponse, conflicted bool, err error) {
+	tx, err := repository.BeginTx(r.Context(), isolation)
+	if err != nil {
+		return nil, false, err
+	}
+	committed := false
+	defer func() {
+		// Also when next panics
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	txRepository := repository.WithTx(tx).WithAudit(audit)
+	afterCommit := &handlers.AfterCommit{}
+	ctx := context.WithValue(r.Context(), handlers.RepoContextKey, txRepository)
+	ctx = context.WithValue(ctx, handlers.AfterCommitContextKey, afterCommit)
+	response = &bufferedResponse{header: make(http.Header)}
+	next.ServeHTTP(response, r.WithContext(ctx))
+
+	if txRepository.Conflicted() {
+		return response, true, nil
+	}
+	if status := response.statusCode(); status < 200 || status > 299 {
+		return response, false, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return response, repo.IsSerializationFailure(err), err
+	}
+	committed = true
+	afterCommit.Run()
+	return response, false, nil
+}
+
+// auditActor names the caller of a request in the audit trail
+func auditActor(ctx context.Context) string {
+	if subject := auth.SubjectFromContext(ctx); subject != "" {
+		return subject
+	}
+	return "anonymous" // Authentication is disabled
+}
+
+// bufferedResponse holds a response back until its transaction is settled
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) statusCode() int {
+	if b.status == 0 {
+		return http.StatusOK
+	}
+	return b.status
+}
+
+// flush writes the response held back to w
+func (b *bufferedResponse) flush(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.statusCode())
+	w.Write(b.body.Bytes())
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/config"
+	"github.com/hotbrandon/go-chi/internal/handlers"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// newUnitOfWorkTestApp returns an app with an empty, migrated sales
+// database, where inserting the coin CONFLICT fails to serialize
+func newUnitOfWorkTestApp(t *testing.T, maxAttempts int) *application {
+	t.Helper()
+	captureLogs(t, nil)
+	app := newTestApp(map[string]config.DatabaseConfig{"sales": sqliteDatabase(t, "sales")})
+	app.cfg.Transactions = config.TransactionsConfig{Isolation: config.IsolationSerializable, MaxAttempts: maxAttempts}
+	seedDatabase(t, app, "sales")
+
+	_, err := app.connectedDBs()["sales"].Exec(`
+		CREATE TRIGGER CONFLICT_TRG BEFORE INSERT ON TRANSACTIONS
+		WHEN NEW.COIN_SYMBOL = 'CONFLICT'
+		BEGIN
+			SELECT RAISE(ABORT, 'ORA-08177: can''t serialize access for this transaction');
+		END`)
+	if err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	return app
+}
+
+// salesTransactions lists what was committed to sales
+func salesTransactions(t *testing.T, app *application) []repo.Transaction {
+	t.Helper()
+	transactions, err := repo.New(app.connectedDBs()["sales"], repo.SQLite).ListTransactions(context.Background(), 1, 100)
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	return transactions
+}
+
+// serveInUnitOfWork runs handler through unitOfWork against sales
+func serveInUnitOfWork(app *application, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	repository := repo.New(repo.Instrument(app.connectedDBs()["sales"], app.queryStats("sales")), repo.SQLite)
+	ctx := context.WithValue(context.Background(), handlers.RepoContextKey, repository)
+	ctx = context.WithValue(ctx, handlers.DBIDContextKey, "sales")
+
+	req := httptest.NewRequest("POST", "/api/sales/crypto/transactions", strings.NewReader("{}"))
+	w := httptest.NewRecorder()
+	app.unitOfWork(handler).ServeHTTP(w, req.WithContext(ctx))
+	return w
+}
+
+// ============================================================================
+// Unit of Work Tests
+// ============================================================================
+
+func TestUnitOfWork_ImportIsAtomic(t *testing.T) {
+	// Arrange: the second row breaks the exchange constraint
+	app := newUnitOfWorkTestApp(t, 1)
+	body := "coin_symbol,transaction_type,quantity,price_per_unit,total_cost,transaction_date,exchange\n" +
+		"BTC,B,1,100,100,2024-01-15,BN\n" +
+		"ETH,B,1,10,10,2024-01-16,XX\n"
+	req := httptest.NewRequest("POST", "/api/sales/crypto/transactions/import", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	// Act
+	app.mount().ServeHTTP(w, req)
+
+	// Assert
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := salesTransactions(t, app); len(got) != 0 {
+		t.Errorf("expected the first row to be rolled back, got %+v", got)
+	}
+}
+
+func TestUnitOfWork_RetriesSerializationFailures(t *testing.T) {
+	// Arrange
+	app := newUnitOfWorkTestApp(t, 3)
+	attempts := 0
+
+	// Act: the first run conflicts, the second succeeds
+	w := serveInUnitOfWork(app, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		coin := "BTC"
+		if attempts == 1 {
+			coin = "CONFLICT"
+		}
+		if err := handlers.MustGetRepo(r.Context()).CreateTransaction(r.Context(), buy(coin, 1, 100, "2024-01-15")); err != nil {
+			http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	// Assert
+	if w.Code != http.StatusCreated || attempts != 2 {
+		t.Fatalf("expected 201 after 2 attempts, got %d after %d: %s", w.Code, attempts, w.Body.String())
+	}
+	if got := salesTransactions(t, app); len(got) != 1 || got[0].CoinSymbol != "BTC" {
+		t.Errorf("expected only the second attempt committed, got %+v", got)
+	}
+}
+
+func TestUnitOfWork_GivesUpAfterMaxAttempts(t *testing.T) {
+	app := newUnitOfWorkTestApp(t, 2)
+	attempts := 0
+
+	w := serveInUnitOfWork(app, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		repository := handlers.MustGetRepo(r.Context())
+		repository.CreateTransaction(r.Context(), buy("BTC", 1, 100, "2024-01-15"))
+		repository.CreateTransaction(r.Context(), buy("CONFLICT", 1, 100, "2024-01-15"))
+		// Answering 2xx does not commit a conflicted transaction
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	if w.Code != http.StatusConflict || attempts != 2 {
+		t.Fatalf("expected 409 after 2 attempts, got %d after %d", w.Code, attempts)
+	}
+	if !strings.Contains(w.Body.String(), "TX_CONFLICT") {
+		t.Errorf("expected code TX_CONFLICT, got %s", w.Body.String())
+	}
+	if got := salesTransactions(t, app); len(got) != 0 {
+		t.Errorf("expected nothing committed, got %+v", got)
+	}
+}
+
+func TestUnitOfWork_RunsOnCommitOnceCommitted(t *testing.T) {
+	// Arrange
+	app := newUnitOfWorkTestApp(t, 3)
+	attempts, committed := 0, 0
+	handler := func(status int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			coin := "BTC"
+			if attempts == 1 {
+				coin = "CONFLICT"
+			}
+			handlers.MustGetRepo(r.Context()).CreateTransaction(r.Context(), buy(coin, 1, 100, "2024-01-15"))
+			handlers.OnCommit(r.Context(), func() { committed++ })
+			w.WriteHeader(status)
+		}
+	}
+
+	// Act: a conflict then a commit, then a rolled back request
+	serveInUnitOfWork(app, handler(http.StatusCreated))
+	serveInUnitOfWork(app, handler(http.StatusInternalServerError))
+
+	// Assert
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+	if committed != 1 {
+		t.Errorf("expected only the committed attempt to run its hooks, got %d", committed)
+	}
+}
+
+func TestUnitOfWork_RollsBackOnPanic(t *testing.T) {
+	app := newUnitOfWorkTestApp(t, 1)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to propagate")
+			}
+		}()
+		serveInUnitOfWork(app, func(w http.ResponseWriter, r *http.Request) {
+			handlers.MustGetRepo(r.Context()).CreateTransaction(r.Context(), buy("BTC", 1, 100, "2024-01-15"))
+			panic("handler bug")
+		})
+	}()
+
+	if got := salesTransactions(t, app); len(got) != 0 {
+		t.Errorf("expected the insert to be rolled back, got %+v", got)
+	}
+}
+
+func TestUnitOfWork_RollsBackWhenClientLeaves(t *testing.T) {
+	// Arrange
+	app := newUnitOfWorkTestApp(t, 1)
+	repository := repo.New(app.connectedDBs()["sales"], repo.SQLite)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), handlers.RepoContextKey, repository))
+	req := httptest.NewRequest("POST", "/api/sales/crypto/transactions", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	// Act: the client disconnects after the insert
+	app.unitOfWork(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlers.MustGetRepo(r.Context()).CreateTransaction(r.Context(), buy("BTC", 1, 100, "2024-01-15"))
+		cancel()
+		w.WriteHeader(http.StatusCreated)
+	})).ServeHTTP(w, req)
+
+	// Assert: nothing committed, nothing answered
+	if got := salesTransactions(t, app); len(got) != 0 {
+		t.Errorf("expected the insert to be rolled back, got %+v", got)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected no response, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUnitOfWork_AuditsWrites(t *testing.T) {
+	// Arrange
+	app := newUnitOfWorkTestApp(t, 1)
+	router := app.mount()
+
+	// Act
+	created := serve(router, "POST", "/api/sales/crypto/transactions", "", buy("BTC", 1, 100, "2024-01-15"))
+	updated := serve(router, "PUT", "/api/sales/crypto/transactions/1", "", buy("BTC", 2, 100, "2024-01-15"))
+	rejected := serve(router, "POST", "/api/sales/crypto/transactions", "", buy("CONFLICT", 1, 100, "2024-01-15"))
+	history := serve(router, "GET", "/api/sales/crypto/transactions/1/history", "", nil)
+	updates := serve(router, "GET", "/api/sales/audit?operation=update", "", nil)
+
+	// Assert
+	if created.Code != http.StatusCreated || updated.Code != http.StatusOK || rejected.Code != http.StatusConflict {
+		t.Fatalf("expected 201, 200 and 409, got %d, %d and %d", created.Code, updated.Code, rejected.Code)
+	}
+
+	var trail struct {
+		History []repo.AuditEntry `json:"history"`
+	}
+	json.NewDecoder(history.Body).Decode(&trail)
+	if len(trail.History) != 2 {
+		t.Fatalf("expected the create and the update only, got %d: %s", history.Code, history.Body.String())
+	}
+	for _, e := range trail.History {
+		if e.Actor != "anonymous" || e.RequestID == nil || *e.RequestID == "" || e.DatabaseID != "sales" {
+			t.Errorf("expected an anonymous entry with a request id, got %+v", e)
+		}
+	}
+	if *trail.History[0].RequestID == *trail.History[1].RequestID {
+		t.Errorf("expected each request to have its own id, got %+v", trail.History)
+	}
+
+	var listed struct {
+		Entries []repo.AuditEntry `json:"entries"`
+	}
+	json.NewDecoder(updates.Body).Decode(&listed)
+	if len(listed.Entries) != 1 || listed.Entries[0].Operation != repo.AuditUpdate {
+		t.Errorf("expected the update only, got %d: %+v", updates.Code, listed.Entries)
+	}
+}
@@ -0,0 +1,160 @@
+// Package admission bounds the number of requests working against a
+// database at once.
+//
+// database/sql blocks callers inside the pool when all connections are busy,
+// with no limit on how many wait or for how long, so a burst ends in a pile
+// of requests hitting the request timeout together. A Controller admits up
+// to MaxInFlight requests, queues up to MaxQueue more for at most MaxWait,
+// and rejects the rest immediately.
+package admission
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned when MaxQueue requests are already waiting.
+	ErrQueueFull = errors.New("admission queue is full")
+	// ErrWaitTimeout is returned when a request waited MaxWait in the queue.
+	ErrWaitTimeout = errors.New("timed out waiting for admission")
+)
+
+// Limits configure a Controller.
+type Limits struct {
+	MaxInFlight int
+	MaxQueue    int
+	MaxWait     time.Duration
+}
+
+// Stats is a snapshot of a Controller. The counters are cumulative.
+type Stats struct {
+	Limits
+	InFlight int
+	Queued   int
+	Admitted uint64
+	Rejected uint64        // queue was full
+	TimedOut uint64        // waited MaxWait without being admitted
+	Waited   time.Duration // total time requests spent queued
+}
+
+// Controller admits requests to one database in FIFO order.
+type Controller struct {
+	mu       sync.Mutex
+	limits   Limits
+	inFlight int
+	waiters  list.List // of chan struct{}, closed when admitted
+
+	admitted uint64
+	rejected uint64
+	timedOut uint64
+	waited   time.Duration
+}
+
+// NewController returns a controller with the given limits.
+func NewController(limits Limits) *Controller {
+	return &Controller{limits: limits}
+}
+
+// SetLimits changes the limits of a live controller. Requests already in
+// flight are not interrupted when MaxInFlight is lowered; waiters are
+// admitted straight away when it is raised.
+func (c *Controller) SetLimits(limits Limits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits = limits
+	c.admitWaitersLocked()
+}
+
+// Acquire waits for a slot and returns the function that gives it back.
+// It fails with ErrQueueFull, ErrWaitTimeout or the context's error.
+func (c *Controller) Acquire(ctx context.Context) (release func(), err error) {
+	c.mu.Lock()
+	if c.inFlight < c.limits.MaxInFlight && c.waiters.Len() == 0 {
+		c.inFlight++
+		c.admitted++
+		c.mu.Unlock()
+		return c.releaseFunc(), nil
+	}
+	if c.waiters.Len() >= c.limits.MaxQueue {
+		c.rejected++
+		c.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	ready := make(chan struct{})
+	elem := c.waiters.PushBack(ready)
+	maxWait := c.limits.MaxWait
+	c.mu.Unlock()
+
+	queuedAt := time.Now()
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		c.mu.Lock()
+		c.waited += time.Since(queuedAt)
+		c.mu.Unlock()
+		return c.releaseFunc(), nil
+	case <-timer.C:
+		err = ErrWaitTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waited += time.Since(queuedAt)
+	select {
+	case <-ready:
+		// Admitted while giving up; hand the slot to the next waiter
+		c.inFlight--
+		c.admitted--
+		c.admitWaitersLocked()
+	default:
+		c.waiters.Remove(elem)
+	}
+	if errors.Is(err, ErrWaitTimeout) {
+		c.timedOut++
+	}
+	return nil, err
+}
+
+func (c *Controller) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.inFlight--
+			c.admitWaitersLocked()
+		})
+	}
+}
+
+func (c *Controller) admitWaitersLocked() {
+	for c.inFlight < c.limits.MaxInFlight && c.waiters.Len() > 0 {
+		ready := c.waiters.Remove(c.waiters.Front()).(chan struct{})
+		c.inFlight++
+		c.admitted++
+		close(ready)
+	}
+}
+
+// Stats returns the current limits, occupancy and counters.
+func (c *Controller) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Limits:   c.limits,
+		InFlight: c.inFlight,
+		Queued:   c.waiters.Len(),
+		Admitted: c.admitted,
+		Rejected: c.rejected,
+		TimedOut: c.timedOut,
+		Waited:   c.waited,
+	}
+}
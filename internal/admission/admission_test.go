@@ -0,0 +1,121 @@
+package admission_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/admission"
+)
+
+// acquireAsync starts an Acquire in the background and returns its result
+func acquireAsync(c *admission.Controller, ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		release, err := c.Acquire(ctx)
+		if err == nil {
+			defer release()
+		}
+		done <- err
+	}()
+	return done
+}
+
+// waitForQueue waits until n requests are queued
+func waitForQueue(t *testing.T, c *admission.Controller, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.Stats().Queued != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued, got %d", n, c.Stats().Queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// ============================================================================
+// Controller Tests
+// ============================================================================
+
+func TestController_QueuesThenAdmits(t *testing.T) {
+	c := admission.NewController(admission.Limits{MaxInFlight: 1, MaxQueue: 1, MaxWait: time.Second})
+	ctx := context.Background()
+
+	release, err := c.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	queued := acquireAsync(c, ctx)
+	waitForQueue(t, c, 1)
+
+	// The queue is full: shed immediately
+	start := time.Now()
+	if _, err := c.Acquire(ctx); !errors.Is(err, admission.ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Error("expected a full queue to reject without waiting")
+	}
+
+	// Releasing the slot admits the waiter
+	release()
+	release() // releasing twice is harmless
+	if err := <-queued; err != nil {
+		t.Fatalf("expected the queued request to be admitted, got %v", err)
+	}
+
+	stats := c.Stats()
+	if stats.Admitted != 2 || stats.Rejected != 1 || stats.InFlight != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestController_WaitTimeout(t *testing.T) {
+	c := admission.NewController(admission.Limits{MaxInFlight: 1, MaxQueue: 5, MaxWait: 20 * time.Millisecond})
+	release, _ := c.Acquire(context.Background())
+	defer release()
+
+	if _, err := c.Acquire(context.Background()); !errors.Is(err, admission.ErrWaitTimeout) {
+		t.Fatalf("expected ErrWaitTimeout, got %v", err)
+	}
+	if stats := c.Stats(); stats.TimedOut != 1 || stats.Queued != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if waited := c.Stats().Waited; waited < 20*time.Millisecond {
+		t.Errorf("expected the queue wait to be counted, got %v", waited)
+	}
+}
+
+func TestController_ContextCancelled(t *testing.T) {
+	c := admission.NewController(admission.Limits{MaxInFlight: 1, MaxQueue: 5, MaxWait: time.Second})
+	release, _ := c.Acquire(context.Background())
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := acquireAsync(c, ctx)
+	waitForQueue(t, c, 1)
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if stats := c.Stats(); stats.Queued != 0 || stats.TimedOut != 0 {
+		t.Errorf("expected the waiter to leave the queue, got %+v", stats)
+	}
+}
+
+func TestController_SetLimitsAdmitsWaiters(t *testing.T) {
+	c := admission.NewController(admission.Limits{MaxInFlight: 1, MaxQueue: 5, MaxWait: time.Second})
+	release, _ := c.Acquire(context.Background())
+	defer release()
+
+	done := acquireAsync(c, context.Background())
+	waitForQueue(t, c, 1)
+
+	c.SetLimits(admission.Limits{MaxInFlight: 2, MaxQueue: 5, MaxWait: time.Second})
+	if err := <-done; err != nil {
+		t.Fatalf("expected raising the limit to admit the waiter, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+// Package apierror writes the API's standard JSON error body, and RFC 7807
+// problem details where a client expects them.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Response is the error body returned by the endpoints: a human-readable
+// title, a message for the user and a machine-readable code.
+type Response struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// Write sends an error response with the given status.
+func Write(w http.ResponseWriter, statusCode int, title, message, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(Response{
+		Error:   title,
+		Message: message,
+		Code:    code,
+	})
+}
+
+// Problem is an RFC 7807 problem details body. Code carries the same
+// machine-readable code as Response, as an extension member.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	Code   string `json:"code"`
+}
+
+// WriteProblem sends an application/problem+json response with the given
+// status. The type is about:blank, so the title is the status text.
+func WriteProblem(w http.ResponseWriter, statusCode int, detail, code string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+		Code:   code,
+	})
+}
@@ -0,0 +1,245 @@
+// Package auth authenticates API callers and authorizes their access to
+// database ids.
+//
+// Authenticators turn a request into a Principal carrying scopes such as
+// "sales:read" or "*:write". RequireDatabaseAccess then checks the scopes
+// against the {database_id} URL parameter and the HTTP method, so it
+// composes with the database middleware mounted on the same route.
+// Authorize refines that per route with a role-based Policy.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/logging"
+)
+
+// Access is the level of access a request needs to a database.
+type Access string
+
+const (
+	AccessRead  Access = "read"
+	AccessWrite Access = "write" // implies read
+)
+
+// AdminScope grants access to the /admin endpoints.
+const AdminScope = "admin"
+
+// Scope grants an access level on one database id, or on all of them when
+// Database is "*". The special scope "admin" has an empty Database.
+type Scope struct {
+	Database string
+	Access   Access
+}
+
+// ParseScope parses "<database_id>:<read|write>", "*:<read|write>" or "admin".
+func ParseScope(s string) (Scope, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == AdminScope {
+		return Scope{Access: AdminScope}, nil
+	}
+	db, access, ok := strings.Cut(s, ":")
+	if !ok || db == "" {
+		return Scope{}, fmt.Errorf("invalid scope %q (expected <database_id>:<read|write>)", s)
+	}
+	switch Access(access) {
+	case AccessRead, AccessWrite:
+	default:
+		return Scope{}, fmt.Errorf("invalid scope %q: access must be read or write", s)
+	}
+	return Scope{Database: db, Access: Access(access)}, nil
+}
+
+// ParseScopes parses a list of scopes, failing on the first invalid one.
+func ParseScopes(values []string) ([]Scope, error) {
+	scopes := make([]Scope, 0, len(values))
+	for _, v := range values {
+		scope, err := ParseScope(v)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+func (s Scope) String() string {
+	if s.Access == AdminScope {
+		return AdminScope
+	}
+	return s.Database + ":" + string(s.Access)
+}
+
+// allows reports whether the scope covers access to dbID.
+func (s Scope) allows(dbID string, access Access) bool {
+	if s.Access == AdminScope {
+		return false
+	}
+	if s.Database != "*" && s.Database != dbID {
+		return false
+	}
+	return s.Access == AccessWrite || access == AccessRead
+}
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Subject string   // key id or token subject, used for audit logging
+	Method  string   // "api_key", "jwt", ...
+	Scopes  []Scope  // what the caller may access
+	Roles   []string // "<role>" or "<database_id>:<role>", see Policy
+}
+
+// CanAccess reports whether the principal may access dbID at the given level.
+func (p *Principal) CanAccess(dbID string, access Access) bool {
+	for _, s := range p.Scopes {
+		if s.allows(dbID, access) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the principal holds exactly the named scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s.String() == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey string
+
+const principalContextKey contextKey = "principal"
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey, p)
+}
+
+// PrincipalFromContext returns the authenticated principal, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey).(*Principal)
+	return p, ok
+}
+
+// SubjectFromContext returns the authenticated subject for audit logging,
+// or "" for unauthenticated requests.
+func SubjectFromContext(ctx context.Context) string {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.Subject
+	}
+	return ""
+}
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// no credentials it understands, so the next authenticator should be tried.
+var ErrNoCredentials = errors.New("no credentials")
+
+// ErrUnavailable is wrapped by an Authenticator that could not check the
+// credentials, such as when its key store is down. The caller is answered
+// 503 rather than told its credentials are invalid.
+var ErrUnavailable = errors.New("authentication unavailable")
+
+// Authenticator identifies the caller of a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Middleware authenticates every request with the first authenticator that
+// recognises its credentials, and responds 401 if none does.
+func Middleware(authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				principal, err := a.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if errors.Is(err, ErrUnavailable) {
+					logging.FromContext(r.Context()).Error("authentication unavailable",
+						"path", r.URL.Path,
+						"error", err)
+					apierror.Write(w, http.StatusServiceUnavailable,
+						"Authentication Unavailable",
+						"Credentials cannot be checked right now. Please try again later.",
+						"AUTH_UNAVAILABLE")
+					return
+				}
+				if err != nil {
+					logging.FromContext(r.Context()).Warn("authentication failed",
+						"path", r.URL.Path,
+						"error", err)
+					writeUnauthorized(w, "The supplied credentials are invalid or have been revoked.")
+					return
+				}
+				logging.AddAttrs(r.Context(), slog.String("user", principal.Subject))
+				logging.FromContext(r.Context()).Debug("request authenticated",
+					"subject", principal.Subject,
+					"method", principal.Method)
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+				return
+			}
+			writeUnauthorized(w, "Authentication is required. Supply an API key or bearer token.")
+		})
+	}
+}
+
+// RequireDatabaseAccess allows the request only if the principal's scopes
+// cover the {database_id} URL parameter at the level implied by the method.
+func RequireDatabaseAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dbID := strings.ToLower(chi.URLParam(r, "database_id"))
+		access := AccessForMethod(r.Method)
+
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok || !principal.CanAccess(dbID, access) {
+			writeForbidden(w, fmt.Sprintf("You do not have %s access to database %q.", access, dbID))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope allows the request only if the principal holds scope.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok || !principal.HasScope(scope) {
+				writeForbidden(w, fmt.Sprintf("This endpoint requires the %q scope.", scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AccessForMethod maps safe methods to read access and everything else to
+// write access.
+func AccessForMethod(method string) Access {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return AccessRead
+	default:
+		return AccessWrite
+	}
+}
+
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="go-chi"`)
+	apierror.Write(w, http.StatusUnauthorized, "Unauthorized", message, "UNAUTHORIZED")
+}
+
+// writeForbidden answers scope and policy denials as problem details.
+func writeForbidden(w http.ResponseWriter, message string) {
+	apierror.WriteProblem(w, http.StatusForbidden, message, "FORBIDDEN")
+}
@@ -0,0 +1,216 @@
+package auth_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/auth"
+)
+
+// ============================================================================
+// Scope Tests
+// ============================================================================
+
+func TestParseScope(t *testing.T) {
+	valid := map[string]string{
+		"sales:read":    "sales:read",
+		"FINANCE:write": "finance:write",
+		"*:read":        "*:read",
+		"admin":         "admin",
+	}
+	for in, want := range valid {
+		scope, err := auth.ParseScope(in)
+		if err != nil {
+			t.Errorf("ParseScope(%q): unexpected error: %v", in, err)
+			continue
+		}
+		if scope.String() != want {
+			t.Errorf("ParseScope(%q) = %q, want %q", in, scope, want)
+		}
+	}
+
+	for _, in := range []string{"", "sales", ":read", "sales:delete"} {
+		if _, err := auth.ParseScope(in); err == nil {
+			t.Errorf("ParseScope(%q): expected an error", in)
+		}
+	}
+}
+
+func TestPrincipal_CanAccess(t *testing.T) {
+	scopes, _ := auth.ParseScopes([]string{"sales:read", "finance:write", "admin"})
+	p := &auth.Principal{Scopes: scopes}
+
+	tests := []struct {
+		db     string
+		access auth.Access
+		want   bool
+	}{
+		{"sales", auth.AccessRead, true},
+		{"sales", auth.AccessWrite, false},
+		{"finance", auth.AccessRead, true}, // write implies read
+		{"finance", auth.AccessWrite, true},
+		{"hr", auth.AccessRead, false},
+	}
+	for _, tt := range tests {
+		if got := p.CanAccess(tt.db, tt.access); got != tt.want {
+			t.Errorf("CanAccess(%s, %s) = %v, want %v", tt.db, tt.access, got, tt.want)
+		}
+	}
+
+	wildcard, _ := auth.ParseScopes([]string{"*:read"})
+	if !(&auth.Principal{Scopes: wildcard}).CanAccess("anything", auth.AccessRead) {
+		t.Error("expected *:read to cover every database")
+	}
+}
+
+// ============================================================================
+// Middleware Tests
+// ============================================================================
+
+// newRouter mounts the auth middleware the same way the API does
+func newRouter(store auth.KeyStore) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/api/{database_id}", func(r chi.Router) {
+		r.Use(auth.Middleware(auth.APIKeyAuthenticator{Store: store}))
+		r.Use(auth.RequireDatabaseAccess)
+		r.Get("/things", func(w http.ResponseWriter, r *http.Request) {
+			p, _ := auth.PrincipalFromContext(r.Context())
+			w.Header().Set("X-Subject", p.Subject)
+			w.WriteHeader(http.StatusOK)
+		})
+		r.Post("/things", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	})
+	return r
+}
+
+func TestMiddleware_APIKeys(t *testing.T) {
+	store, err := auth.NewFileKeyStore(filepath.Join(t.TempDir(), "keys.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	key, plaintext, err := auth.MintKey(ctx, store, "reporting", []string{"sales:read", "finance:write"})
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+	old, revoked, _ := auth.MintKey(ctx, store, "old", []string{"sales:read"})
+	if err := store.Revoke(ctx, old.ID); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+
+	router := newRouter(store)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		key    string
+		want   int
+	}{
+		{"no key", "GET", "/api/sales/things", "", "", http.StatusUnauthorized},
+		{"unknown key", "GET", "/api/sales/things", "X-API-Key", "gck_nope", http.StatusUnauthorized},
+		{"revoked key", "GET", "/api/sales/things", "X-API-Key", revoked, http.StatusUnauthorized},
+		{"read allowed", "GET", "/api/sales/things", "X-API-Key", plaintext, http.StatusOK},
+		{"bearer header", "GET", "/api/SALES/things", "Authorization", "Bearer " + plaintext, http.StatusOK},
+		{"write denied", "POST", "/api/sales/things", "X-API-Key", plaintext, http.StatusForbidden},
+		{"write allowed", "POST", "/api/finance/things", "X-API-Key", plaintext, http.StatusCreated},
+		{"other database", "GET", "/api/hr/things", "X-API-Key", plaintext, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusOK && w.Header().Get("X-Subject") != "api_key:"+key.ID {
+				t.Errorf("expected principal subject api_key:%s, got %q", key.ID, w.Header().Get("X-Subject"))
+			}
+		})
+	}
+}
+
+func TestMiddleware_KeyStoreUnavailable(t *testing.T) {
+	// Arrange: the key database cannot be reached
+	store := auth.NewSQLKeyStore(func() (*sql.DB, error) {
+		return nil, errors.New("database recently failed")
+	})
+	req := httptest.NewRequest("GET", "/api/sales/things", nil)
+	req.Header.Set("X-API-Key", "gck_valid")
+	w := httptest.NewRecorder()
+
+	// Act
+	newRouter(store).ServeHTTP(w, req)
+
+	// Assert: the key is not reported as invalid
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"code":"AUTH_UNAVAILABLE"`) {
+		t.Errorf("expected code AUTH_UNAVAILABLE, got %s", w.Body.String())
+	}
+}
+
+// ============================================================================
+// FileKeyStore Tests
+// ============================================================================
+
+func TestFileKeyStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	ctx := context.Background()
+
+	store, _ := auth.NewFileKeyStore(path)
+	key, plaintext, err := auth.MintKey(ctx, store, "ci", []string{"*:read"})
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+
+	// A second store over the same file sees the key, but never plaintext
+	reopened, err := auth.NewFileKeyStore(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	found, err := reopened.Lookup(ctx, auth.HashKey(plaintext))
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if found.ID != key.ID {
+		t.Errorf("expected key %s, got %s", key.ID, found.ID)
+	}
+
+	if err := reopened.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	found, _ = store.Lookup(ctx, auth.HashKey(plaintext))
+	if !found.Revoked() {
+		t.Error("expected the original store to pick up the revocation from disk")
+	}
+
+	if err := store.Revoke(ctx, "missing"); !errors.Is(err, auth.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestMintKey_RejectsInvalidScopes(t *testing.T) {
+	store, _ := auth.NewFileKeyStore(filepath.Join(t.TempDir(), "keys.yaml"))
+	if _, _, err := auth.MintKey(context.Background(), store, "bad", []string{"sales:delete"}); err == nil {
+		t.Error("expected invalid scope to be rejected")
+	}
+}
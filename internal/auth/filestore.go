@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileKeyStore keeps API keys in a YAML file. The file is re-read when it
+// changes on disk, so keys can also be managed by hand, and rewritten
+// atomically when keys are minted or revoked through the API.
+type FileKeyStore struct {
+	path string
+
+	mu   sync.Mutex
+	info os.FileInfo // of the file the keys were read from
+	keys []APIKey
+}
+
+type keyFile struct {
+	Keys []APIKey `yaml:"keys"`
+}
+
+// NewFileKeyStore opens the key file at path. A missing file is treated as
+// empty and created on the first mint.
+func NewFileKeyStore(path string) (*FileKeyStore, error) {
+	s := &FileKeyStore{path: path}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refreshLocked(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// refreshLocked reloads the file if it was replaced or modified. Writes go
+// through a rename, so comparing file identity catches changes made within
+// the filesystem's timestamp granularity.
+func (s *FileKeyStore) refreshLocked() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.keys, s.info = nil, nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.info != nil && os.SameFile(s.info, info) &&
+		info.ModTime().Equal(s.info.ModTime()) && info.Size() == s.info.Size() {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var f keyFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parse api key file %s: %w", s.path, err)
+	}
+	s.keys, s.info = f.Keys, info
+	return nil
+}
+
+// writeLocked replaces the file with the current keys.
+func (s *FileKeyStore) writeLocked() error {
+	data, err := yaml.Marshal(keyFile{Keys: s.keys})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".api_keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(s.path); err == nil {
+		s.info = info
+	}
+	return nil
+}
+
+func (s *FileKeyStore) Lookup(ctx context.Context, hash string) (APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refreshLocked(); err != nil {
+		return APIKey{}, err
+	}
+	for _, k := range s.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+	return APIKey{}, ErrKeyNotFound
+}
+
+func (s *FileKeyStore) List(ctx context.Context) ([]APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refreshLocked(); err != nil {
+		return nil, err
+	}
+	keys := append([]APIKey(nil), s.keys...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *FileKeyStore) Create(ctx context.Context, key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refreshLocked(); err != nil {
+		return err
+	}
+	s.keys = append(s.keys, key)
+	return s.writeLocked()
+}
+
+func (s *FileKeyStore) Revoke(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refreshLocked(); err != nil {
+		return err
+	}
+	for i := range s.keys {
+		if s.keys[i].ID == id {
+			if s.keys[i].RevokedAt == nil {
+				now := time.Now().UTC().Truncate(time.Second)
+				s.keys[i].RevokedAt = &now
+			}
+			return s.writeLocked()
+		}
+	}
+	return ErrKeyNotFound
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minJWKSRefresh bounds how often an unknown key id can force a refetch, so
+// tokens with made-up kids cannot hammer the identity provider.
+const minJWKSRefresh = 30 * time.Second
+
+// JWKS fetches and caches the public keys published at a JSON Web Key Set
+// URL. Keys are refreshed after the TTL, or early when a token names a key id
+// that is not cached yet (the provider rotated its keys).
+//
+// Fetches run outside the lock, one at a time: callers that need the result
+// wait for the running fetch, and callers holding a cached key are served
+// from the cache meanwhile.
+type JWKS struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	refreshing  *jwksRefresh // nil when no fetch is running
+}
+
+// jwksRefresh is a fetch in progress; err is set before done is closed.
+type jwksRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKS returns a key set for url, cached for ttl.
+func NewJWKS(url string, client *http.Client, ttl time.Duration) *JWKS {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &JWKS{url: url, client: client, ttl: ttl}
+}
+
+// Key returns the public key with the given key id.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	key, cached := j.keys[kid]
+	stale := time.Since(j.fetchedAt) > j.ttl
+	canRefresh := time.Since(j.attemptedAt) > minJWKSRefresh
+
+	if cached {
+		// Keep serving the known key while the keys are refetched, and
+		// while the provider is down
+		if stale && canRefresh {
+			j.refreshLocked(ctx)
+		}
+		j.mu.Unlock()
+		return key, nil
+	}
+	if !stale && !canRefresh && j.refreshing == nil {
+		j.mu.Unlock()
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	refresh := j.refreshLocked(ctx)
+	j.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	if refresh.err != nil {
+		return nil, refresh.err
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// refreshLocked returns the running fetch, starting one if none is. The
+// fetch outlives the caller that started it, as others may be waiting on it.
+func (j *JWKS) refreshLocked(ctx context.Context) *jwksRefresh {
+	if j.refreshing != nil {
+		return j.refreshing
+	}
+	refresh := &jwksRefresh{done: make(chan struct{})}
+	j.refreshing = refresh
+	j.attemptedAt = time.Now()
+
+	go func() {
+		keys, err := j.fetch(context.WithoutCancel(ctx))
+
+		j.mu.Lock()
+		if err != nil {
+			slog.Warn("jwks refresh failed", "error", err)
+		} else {
+			j.keys = keys
+			j.fetchedAt = time.Now()
+		}
+		refresh.err = err
+		j.refreshing = nil
+		j.mu.Unlock()
+		close(refresh.done)
+	}()
+	return refresh
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			// One unsupported key must not take down the others
+			slog.Warn("skipping jwks key", "kid", k.Kid, "error", err)
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+// jwk is a JSON Web Key (RFC 7517), limited to RSA and EC public keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("rsa modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("rsa exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent out of range")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("ec x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("ec y: %w", err)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("ec coordinates have the wrong length")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTAuthenticator authenticates "Authorization: Bearer <jwt>" requests
+// signed with RS256 or ES256 by a key from the JWKS.
+//
+// Database access comes from two claims. Values of the databases claim are
+// database ids (granting read access) or full scopes such as "sales:write".
+// Values of the groups claim are looked up in GroupScopes, which maps a
+// group name to the ids or scopes its members get. The roles claim carries
+// Policy roles as-is.
+type JWTAuthenticator struct {
+	Keys     *JWKS
+	Issuer   string // required iss, if set
+	Audience string // required aud, if set
+
+	DatabasesClaim string
+	GroupsClaim    string
+	GroupScopes    map[string][]string
+	RolesClaim     string
+}
+
+// Only asymmetric algorithms: accepting HS256 would let anyone holding the
+// public key forge tokens.
+var jwtMethods = []string{"RS256", "ES256"}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	raw, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || strings.HasPrefix(raw, KeyPrefix) {
+		return nil, ErrNoCredentials
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtMethods),
+		jwt.WithExpirationRequired(),
+	}
+	if a.Issuer != "" {
+		options = append(options, jwt.WithIssuer(a.Issuer))
+	}
+	if a.Audience != "" {
+		options = append(options, jwt.WithAudience(a.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.Keys.Key(r.Context(), kid)
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: %w", err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, errors.New("jwt: missing sub claim")
+	}
+
+	return &Principal{
+		Subject: subject,
+		Method:  "jwt",
+		Scopes:  a.scopesFromClaims(claims),
+		Roles:   claimStrings(claims, a.rolesClaim()),
+	}, nil
+}
+
+// scopesFromClaims maps the databases and groups claims to scopes. Values
+// that are not valid ids or scopes are ignored rather than rejecting the
+// token, since the claims are shared with other applications.
+func (a *JWTAuthenticator) scopesFromClaims(claims jwt.MapClaims) []Scope {
+	var values []string
+	values = append(values, claimStrings(claims, a.databasesClaim())...)
+	for _, group := range claimStrings(claims, a.groupsClaim()) {
+		values = append(values, a.GroupScopes[group]...)
+	}
+
+	var scopes []Scope
+	for _, v := range values {
+		if !strings.Contains(v, ":") && v != AdminScope {
+			// Write access must be granted explicitly
+			v += ":" + string(AccessRead)
+		}
+		if scope, err := ParseScope(v); err == nil {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func (a *JWTAuthenticator) databasesClaim() string {
+	if a.DatabasesClaim == "" {
+		return "databases"
+	}
+	return a.DatabasesClaim
+}
+
+func (a *JWTAuthenticator) groupsClaim() string {
+	if a.GroupsClaim == "" {
+		return "groups"
+	}
+	return a.GroupsClaim
+}
+
+func (a *JWTAuthenticator) rolesClaim() string {
+	if a.RolesClaim == "" {
+		return "roles"
+	}
+	return a.RolesClaim
+}
+
+// claimStrings reads a claim that is either a string (space separated, as
+// with the standard scope claim) or an array of strings.
+func claimStrings(claims jwt.MapClaims, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,363 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hotbrandon/go-chi/internal/auth"
+)
+
+// fakeIdP serves a JWKS for a mutable set of keys and counts the fetches.
+// When gate is set, fetches wait for it to be closed.
+type fakeIdP struct {
+	*httptest.Server
+	fetches atomic.Int32
+	gate    chan struct{}
+
+	mu   sync.Mutex
+	keys []map[string]string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	idp := &fakeIdP{}
+	idp.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idp.fetches.Add(1)
+		if idp.gate != nil {
+			<-idp.gate
+		}
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"keys": idp.keys})
+	}))
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *fakeIdP) publishRSA(kid string, key *rsa.PublicKey) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keys = append(idp.keys, map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": b64(key.N.Bytes()),
+		"e": b64(big.NewInt(int64(key.E)).Bytes()),
+	})
+}
+
+func (idp *fakeIdP) publishEC(kid string, key *ecdsa.PublicKey) {
+	raw, _ := key.Bytes() // 0x04 || X || Y
+	size := (len(raw) - 1) / 2
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keys = append(idp.keys, map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64(raw[1 : 1+size]),
+		"y": b64(raw[1+size:]),
+	})
+}
+
+func (idp *fakeIdP) replaceKeys() {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keys = nil
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+// validClaims returns claims accepted by newJWTAuthenticator
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "alice@example.com",
+		"iss": "https://idp.example.com",
+		"aud": "go-chi",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newJWTAuthenticator(idp *fakeIdP, ttl time.Duration) *auth.JWTAuthenticator {
+	return &auth.JWTAuthenticator{
+		Keys:     auth.NewJWKS(idp.URL, nil, ttl),
+		Issuer:   "https://idp.example.com",
+		Audience: "go-chi",
+		GroupScopes: map[string][]string{
+			"finance-team": {"finance:write"},
+			"auditors":     {"*:read"},
+		},
+	}
+}
+
+func bearer(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/sales/transactions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// ============================================================================
+// JWT Authenticator Tests
+// ============================================================================
+
+func TestJWTAuthenticator_Tokens(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	idp := newFakeIdP(t)
+	idp.publishRSA("rsa-1", &rsaKey.PublicKey)
+	idp.publishEC("ec-1", &ecKey.PublicKey)
+	a := newJWTAuthenticator(idp, time.Hour)
+
+	with := func(key string, value any) jwt.MapClaims {
+		claims := validClaims()
+		claims[key] = value
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		wantOK bool
+	}{
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()), true},
+		{"ES256", sign(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims()), true},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with("exp", time.Now().Add(-time.Minute).Unix())), false},
+		{"no expiry", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with("exp", nil)), false},
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with("iss", "https://evil.example.com")), false},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with("aud", "other-app")), false},
+		{"no subject", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with("sub", "")), false},
+		{"unknown key", sign(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, validClaims()), false},
+		{"HS256", sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("shared-secret"), validClaims()), false},
+		{"garbage", "not.a.jwt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.Authenticate(bearer(tt.token))
+			if tt.wantOK {
+				if err != nil {
+					t.Fatalf("expected token to be accepted: %v", err)
+				}
+				if principal.Subject != "alice@example.com" || principal.Method != "jwt" {
+					t.Errorf("unexpected principal %+v", principal)
+				}
+				return
+			}
+			if err == nil {
+				t.Error("expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticator_LeavesAPIKeysAlone(t *testing.T) {
+	a := newJWTAuthenticator(newFakeIdP(t), time.Hour)
+
+	if _, err := a.Authenticate(bearer(auth.KeyPrefix + "abc_def")); err != auth.ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials for an API key, got %v", err)
+	}
+	if _, err := a.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil)); err != auth.ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials without a header, got %v", err)
+	}
+}
+
+func TestJWTAuthenticator_ClaimsToScopes(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp := newFakeIdP(t)
+	idp.publishRSA("rsa-1", &rsaKey.PublicKey)
+	a := newJWTAuthenticator(idp, time.Hour)
+
+	claims := validClaims()
+	claims["databases"] = []any{"sales", "hr:read", "not a scope:x"}
+	claims["groups"] = "finance-team unknown-group"
+	principal, err := a.Authenticate(bearer(sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		db     string
+		access auth.Access
+		want   bool
+	}{
+		{"sales", auth.AccessRead, true},
+		{"sales", auth.AccessWrite, false}, // a bare id only grants read
+		{"hr", auth.AccessRead, true},
+		{"hr", auth.AccessWrite, false},
+		{"finance", auth.AccessWrite, true}, // via finance-team
+		{"marketing", auth.AccessRead, false},
+	}
+	for _, tt := range tests {
+		if got := principal.CanAccess(tt.db, tt.access); got != tt.want {
+			t.Errorf("CanAccess(%s, %s) = %v, want %v", tt.db, tt.access, got, tt.want)
+		}
+	}
+}
+
+func TestJWTAuthenticator_MiddlewareSetsSubject(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp := newFakeIdP(t)
+	idp.publishRSA("rsa-1", &rsaKey.PublicKey)
+	a := newJWTAuthenticator(idp, time.Hour)
+
+	var subject string
+	handler := auth.Middleware(a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = auth.SubjectFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, bearer(sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims())))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if subject != "alice@example.com" {
+		t.Errorf("expected subject in context, got %q", subject)
+	}
+}
+
+// ============================================================================
+// JWKS Tests
+// ============================================================================
+
+func TestJWKS_KeyRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp := newFakeIdP(t)
+	idp.publishRSA("old", &oldKey.PublicKey)
+	a := newJWTAuthenticator(idp, 300*time.Millisecond)
+
+	if _, err := a.Authenticate(bearer(sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims()))); err != nil {
+		t.Fatalf("expected old key to be accepted: %v", err)
+	}
+
+	// Unknown key ids do not refetch more than once per refresh interval
+	for range 5 {
+		a.Authenticate(bearer(sign(t, jwt.SigningMethodRS256, "bogus", oldKey, validClaims())))
+	}
+	if got := idp.fetches.Load(); got != 1 {
+		t.Errorf("expected 1 jwks fetch, got %d", got)
+	}
+
+	// The provider rotates; once the cache expires the new key is picked up
+	idp.replaceKeys()
+	idp.publishRSA("new", &newKey.PublicKey)
+	time.Sleep(350 * time.Millisecond)
+
+	if _, err := a.Authenticate(bearer(sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims()))); err != nil {
+		t.Fatalf("expected rotated key to be accepted: %v", err)
+	}
+	if _, err := a.Authenticate(bearer(sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims()))); err == nil {
+		t.Error("expected retired key to be rejected")
+	}
+}
+
+func TestJWKS_ServesCachedKeysWhenProviderIsDown(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp := newFakeIdP(t)
+	idp.publishRSA("rsa-1", &rsaKey.PublicKey)
+	a := newJWTAuthenticator(idp, 10*time.Millisecond)
+	token := sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims())
+
+	if _, err := a.Authenticate(bearer(token)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	idp.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := a.Authenticate(bearer(token)); err != nil {
+		t.Errorf("expected cached key to be used while the provider is down: %v", err)
+	}
+}
+
+func TestJWKS_ConcurrentCallersShareOneFetch(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp := newFakeIdP(t)
+	idp.publishRSA("rsa-1", &rsaKey.PublicKey)
+	idp.gate = make(chan struct{})
+	keys := auth.NewJWKS(idp.URL, nil, time.Hour)
+
+	// Arrange: callers pile up behind a slow provider
+	errs := make(chan error, 10)
+	for range 10 {
+		go func() {
+			_, err := keys.Key(context.Background(), "rsa-1")
+			errs <- err
+		}()
+	}
+	for idp.fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Act
+	close(idp.gate)
+
+	// Assert
+	for range 10 {
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if got := idp.fetches.Load(); got != 1 {
+		t.Errorf("expected 1 jwks fetch, got %d", got)
+	}
+}
+
+func TestJWKS_ServesCachedKeysDuringRefresh(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp := newFakeIdP(t)
+	idp.publishRSA("rsa-1", &rsaKey.PublicKey)
+	keys := auth.NewJWKS(idp.URL, nil, 10*time.Millisecond)
+	if _, err := keys.Key(context.Background(), "rsa-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Arrange: once the cache expires, an unknown key id starts a refetch
+	// that the provider hangs on
+	idp.gate = make(chan struct{})
+	time.Sleep(20 * time.Millisecond)
+	unknown := make(chan error, 1)
+	go func() {
+		_, err := keys.Key(context.Background(), "rotated")
+		unknown <- err
+	}()
+	for idp.fetches.Load() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Act
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := keys.Key(ctx, "rsa-1")
+
+	// Assert
+	if err != nil {
+		t.Errorf("expected the cached key while refreshing: %v", err)
+	}
+	close(idp.gate)
+	if err := <-unknown; err == nil {
+		t.Error("expected the unknown key id to be rejected")
+	}
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Permission allows one HTTP method ("*" for any) on a chi route pattern
+// written relative to the database route, such as "GET /crypto/transactions".
+// A pattern ending in "/*" covers every route below it.
+type Permission struct {
+	Method  string
+	Pattern string
+}
+
+// ParsePermission parses "<METHOD> <pattern>".
+func ParsePermission(s string) (Permission, error) {
+	method, pattern, _ := strings.Cut(strings.TrimSpace(s), " ")
+	pattern = strings.TrimSpace(pattern)
+	if method == "" || !strings.HasPrefix(pattern, "/") {
+		return Permission{}, fmt.Errorf("invalid permission %q (expected \"<METHOD> /<route>\")", s)
+	}
+	return Permission{Method: strings.ToUpper(method), Pattern: pattern}, nil
+}
+
+func (p Permission) String() string {
+	return p.Method + " " + p.Pattern
+}
+
+func (p Permission) allows(method, pattern string) bool {
+	if p.Method != "*" && p.Method != method {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(p.Pattern, "/*"); ok {
+		return pattern == prefix || strings.HasPrefix(pattern, prefix+"/")
+	}
+	return p.Pattern == pattern
+}
+
+// DefaultRoles are the roles used when the configuration defines none.
+var DefaultRoles = map[string][]string{
+	"viewer": {
+		"GET /crypto/transactions",
+		"GET /crypto/transactions/{id}",
+		"GET /crypto/holdings",
+	},
+	"trader": {
+		"GET /crypto/*",
+		"POST /crypto/transactions",
+	},
+	"admin": {
+		"* /crypto/*",
+		"GET /audit",
+	},
+}
+
+// DefaultScopeRoles grants every principal a role per database from its
+// scopes, so keys minted before roles existed keep working.
+var DefaultScopeRoles = map[string]string{
+	string(AccessRead):  "viewer",
+	string(AccessWrite): "trader",
+}
+
+// PolicyRules is the configuration a Policy is built from.
+type PolicyRules struct {
+	// Roles maps a role name to its permissions (DefaultRoles if empty)
+	Roles map[string][]string
+	// Databases replaces the permissions of some roles on one database id
+	Databases map[string]map[string][]string
+	// ScopeRoles maps a scope access level to the role it implies
+	// (DefaultScopeRoles if empty)
+	ScopeRoles map[string]string
+}
+
+// Policy decides which routes a principal may call on each database, based
+// on its roles. A principal holds a role on a database when:
+//   - one of its roles is "<role>", "*:<role>" or "<database_id>:<role>", or
+//   - one of its scopes on the database maps to the role via ScopeRoles.
+type Policy struct {
+	roles      map[string][]Permission
+	databases  map[string]map[string][]Permission
+	scopeRoles map[Access]string
+}
+
+// NewPolicy builds a policy, failing on invalid permissions or on scope
+// roles that are not defined.
+func NewPolicy(rules PolicyRules) (*Policy, error) {
+	roles := rules.Roles
+	if len(roles) == 0 {
+		roles = DefaultRoles
+	}
+	scopeRoles := rules.ScopeRoles
+	if len(scopeRoles) == 0 {
+		scopeRoles = DefaultScopeRoles
+	}
+
+	p := &Policy{
+		roles:      make(map[string][]Permission, len(roles)),
+		databases:  make(map[string]map[string][]Permission, len(rules.Databases)),
+		scopeRoles: make(map[Access]string, len(scopeRoles)),
+	}
+
+	for role, values := range roles {
+		perms, err := parsePermissions(role, values)
+		if err != nil {
+			return nil, err
+		}
+		p.roles[strings.ToLower(role)] = perms
+	}
+
+	for dbID, overrides := range rules.Databases {
+		dbRoles := make(map[string][]Permission, len(overrides))
+		for role, values := range overrides {
+			role = strings.ToLower(role)
+			if _, ok := p.roles[role]; !ok {
+				return nil, fmt.Errorf("policy for database %q: unknown role %q", dbID, role)
+			}
+			perms, err := parsePermissions(role, values)
+			if err != nil {
+				return nil, err
+			}
+			dbRoles[role] = perms
+		}
+		p.databases[strings.ToLower(dbID)] = dbRoles
+	}
+
+	for access, role := range scopeRoles {
+		switch Access(access) {
+		case AccessRead, AccessWrite:
+		default:
+			return nil, fmt.Errorf("scope role for %q: access must be read or write", access)
+		}
+		role = strings.ToLower(role)
+		if _, ok := p.roles[role]; !ok {
+			return nil, fmt.Errorf("scope role for %q: unknown role %q", access, role)
+		}
+		p.scopeRoles[Access(access)] = role
+	}
+
+	return p, nil
+}
+
+func parsePermissions(role string, values []string) ([]Permission, error) {
+	perms := make([]Permission, 0, len(values))
+	for _, v := range values {
+		perm, err := ParsePermission(v)
+		if err != nil {
+			return nil, fmt.Errorf("role %q: %w", role, err)
+		}
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
+
+// HasRole reports whether the policy defines the role.
+func (p *Policy) HasRole(role string) bool {
+	_, ok := p.roles[strings.ToLower(role)]
+	return ok
+}
+
+// RolesFor returns the sorted roles the principal holds on dbID.
+func (p *Policy) RolesFor(principal *Principal, dbID string) []string {
+	held := make(map[string]bool)
+	for _, r := range principal.Roles {
+		db, role, qualified := strings.Cut(strings.ToLower(r), ":")
+		if !qualified {
+			role, db = db, "*"
+		}
+		if db == "*" || db == dbID {
+			held[role] = true
+		}
+	}
+	for _, s := range principal.Scopes {
+		if s.allows(dbID, AccessRead) {
+			held[p.scopeRoles[AccessRead]] = true
+		}
+		if s.allows(dbID, AccessWrite) {
+			held[p.scopeRoles[AccessWrite]] = true
+		}
+	}
+	delete(held, "")
+
+	roles := make([]string, 0, len(held))
+	for role := range held {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Allows reports whether the principal may call the route pattern with the
+// given method on dbID.
+func (p *Policy) Allows(principal *Principal, dbID, method, pattern string) bool {
+	for _, role := range p.RolesFor(principal, dbID) {
+		perms, overridden := p.databases[dbID][role]
+		if !overridden {
+			perms = p.roles[role]
+		}
+		for _, perm := range perms {
+			if perm.allows(method, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize allows the request only if the policy permits the principal's
+// roles on {database_id} to call the matched route. The route pattern is
+// looked up in the router, since the middleware runs before sub-routing;
+// routePrefix (the pattern of the database route) is trimmed from it.
+// Requests that match no route are passed on for the router to reject.
+func Authorize(policy *Policy, routePrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := chi.RouteContext(r.Context())
+			path := r.URL.RawPath
+			if path == "" {
+				path = r.URL.Path
+			}
+			pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, path)
+			if pattern == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			pattern = strings.TrimPrefix(pattern, routePrefix)
+
+			dbID := strings.ToLower(chi.URLParam(r, "database_id"))
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok || !policy.Allows(principal, dbID, r.Method, pattern) {
+				writeForbidden(w, fmt.Sprintf("Your roles on database %q do not permit %s %s.",
+					dbID, r.Method, pattern))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package auth_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/auth"
+)
+
+// ============================================================================
+// Policy Tests
+// ============================================================================
+
+func TestParsePermission(t *testing.T) {
+	perm, err := auth.ParsePermission("get /crypto/transactions/{id}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if perm.String() != "GET /crypto/transactions/{id}" {
+		t.Errorf("unexpected permission %q", perm)
+	}
+
+	for _, in := range []string{"", "GET", "/crypto/transactions", "GET crypto"} {
+		if _, err := auth.ParsePermission(in); err == nil {
+			t.Errorf("ParsePermission(%q): expected an error", in)
+		}
+	}
+}
+
+func TestNewPolicy_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules auth.PolicyRules
+	}{
+		{"invalid permission", auth.PolicyRules{
+			Roles: map[string][]string{"viewer": {"GET"}},
+		}},
+		{"override of unknown role", auth.PolicyRules{
+			Databases: map[string]map[string][]string{"sales": {"auditor": {"GET /crypto/*"}}},
+		}},
+		{"scope role not defined", auth.PolicyRules{
+			Roles:      map[string][]string{"reader": {"GET /crypto/*"}},
+			ScopeRoles: map[string]string{"read": "viewer"},
+		}},
+		{"invalid scope access", auth.PolicyRules{
+			ScopeRoles: map[string]string{"delete": "admin"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.NewPolicy(tt.rules); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestPolicy_RolesFor(t *testing.T) {
+	policy, err := auth.NewPolicy(auth.PolicyRules{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scopes, _ := auth.ParseScopes([]string{"sales:read", "finance:write"})
+	p := &auth.Principal{Scopes: scopes, Roles: []string{"hr:admin", "finance:admin"}}
+
+	tests := map[string][]string{
+		"sales":   {"viewer"},
+		"finance": {"admin", "trader", "viewer"},
+		"hr":      {"admin"}, // still needs a scope on hr to get past RequireDatabaseAccess
+		"other":   {},
+	}
+	for db, want := range tests {
+		if got := policy.RolesFor(p, db); !reflect.DeepEqual(got, want) {
+			t.Errorf("RolesFor(%s) = %v, want %v", db, got, want)
+		}
+	}
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	policy, err := auth.NewPolicy(auth.PolicyRules{
+		Roles: auth.DefaultRoles,
+		Databases: map[string]map[string][]string{
+			// Read-only trading desk on hr
+			"hr": {"trader": {"GET /crypto/*"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	viewer := &auth.Principal{Roles: []string{"viewer"}}
+	trader := &auth.Principal{Roles: []string{"trader"}}
+	admin := &auth.Principal{Roles: []string{"sales:admin"}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		db        string
+		method    string
+		pattern   string
+		want      bool
+	}{
+		{"viewer lists", viewer, "sales", "GET", "/crypto/transactions", true},
+		{"viewer cannot create", viewer, "sales", "POST", "/crypto/transactions", false},
+		{"trader creates", trader, "sales", "POST", "/crypto/transactions", true},
+		{"trader cannot update", trader, "sales", "PUT", "/crypto/transactions/{id}", false},
+		{"trader cannot import", trader, "sales", "POST", "/crypto/transactions/import", false},
+		{"override applies", trader, "hr", "POST", "/crypto/transactions", false},
+		{"override keeps reads", trader, "hr", "GET", "/crypto/transactions", true},
+		{"admin deletes", admin, "sales", "DELETE", "/crypto/transactions/{id}", true},
+		{"admin role is per database", admin, "finance", "DELETE", "/crypto/transactions/{id}", false},
+		{"wildcard does not match siblings", admin, "sales", "GET", "/cryptography", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Allows(tt.principal, tt.db, tt.method, tt.pattern); got != tt.want {
+				t.Errorf("Allows(%s %s on %s) = %v, want %v", tt.method, tt.pattern, tt.db, got, tt.want)
+			}
+		})
+	}
+}
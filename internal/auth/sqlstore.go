@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// SQLKeyStore keeps API keys in an Oracle API_KEYS table (see tables.md).
+// The connection is obtained per call so that the application's lazy
+// reconnect logic applies to the key database too.
+type SQLKeyStore struct {
+	getDB func() (*sql.DB, error)
+}
+
+// NewSQLKeyStore returns a store using the database returned by getDB.
+func NewSQLKeyStore(getDB func() (*sql.DB, error)) *SQLKeyStore {
+	return &SQLKeyStore{getDB: getDB}
+}
+
+const selectKeyColumns = `
+	SELECT key_id, name, key_hash, scopes, roles, created_at, revoked_at
+	FROM api_keys`
+
+func scanKey(scan func(...any) error) (APIKey, error) {
+	var k APIKey
+	var scopes string
+	var roles sql.NullString
+	var revokedAt sql.NullTime
+	if err := scan(&k.ID, &k.Name, &k.Hash, &scopes, &roles, &k.CreatedAt, &revokedAt); err != nil {
+		return APIKey{}, err
+	}
+	k.Scopes = strings.Fields(scopes)
+	k.Roles = strings.Fields(roles.String)
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return k, nil
+}
+
+func (s *SQLKeyStore) Lookup(ctx context.Context, hash string) (APIKey, error) {
+	db, err := s.getDB()
+	if err != nil {
+		return APIKey{}, err
+	}
+	row := db.QueryRowContext(ctx, selectKeyColumns+` WHERE key_hash = :1`, hash)
+	key, err := scanKey(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrKeyNotFound
+	}
+	return key, err
+}
+
+func (s *SQLKeyStore) List(ctx context.Context) ([]APIKey, error) {
+	db, err := s.getDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, selectKeyColumns+` ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		key, err := scanKey(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *SQLKeyStore) Create(ctx context.Context, key APIKey) error {
+	db, err := s.getDB()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO api_keys (key_id, name, key_hash, scopes, roles, created_at)
+		VALUES (:1, :2, :3, :4, :5, :6)`,
+		key.ID, key.Name, key.Hash, strings.Join(key.Scopes, " "), strings.Join(key.Roles, " "), key.CreatedAt)
+	return err
+}
+
+func (s *SQLKeyStore) Revoke(ctx context.Context, id string) error {
+	db, err := s.getDB()
+	if err != nil {
+		return err
+	}
+	result, err := db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = SYSDATE
+		WHERE key_id = :1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		// Either unknown or already revoked; distinguish for the caller
+		var exists int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM api_keys WHERE key_id = :1`, id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrKeyNotFound
+		}
+	}
+	return nil
+}
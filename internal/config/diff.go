@@ -0,0 +1,57 @@
+package config
+
+import (
+	"slices"
+	"sort"
+)
+
+// DatabaseChanges describes how the set of databases differs between two
+// configurations.
+type DatabaseChanges struct {
+	Added   []string // present only in the new configuration
+	Removed []string // present only in the old configuration
+	Changed []string // connection settings differ; the pool must be re-dialled
+	Retuned []string // only pool or admission settings differ; applied in place
+}
+
+// Empty reports whether there is nothing to apply.
+func (c DatabaseChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 &&
+		len(c.Changed) == 0 && len(c.Retuned) == 0
+}
+
+// DiffDatabases compares two database maps keyed by id. Each result slice is
+// sorted so that changes are applied and logged in a stable order.
+func DiffDatabases(old, new map[string]DatabaseConfig) DatabaseChanges {
+	var changes DatabaseChanges
+
+	for id, newDB := range new {
+		oldDB, exists := old[id]
+		switch {
+		case !exists:
+			changes.Added = append(changes.Added, id)
+		case !sameConnection(oldDB, newDB):
+			changes.Changed = append(changes.Changed, id)
+		case !oldDB.Pool.Equal(newDB.Pool) || oldDB.Admission != newDB.Admission:
+			changes.Retuned = append(changes.Retuned, id)
+		}
+	}
+	for id := range old {
+		if _, exists := new[id]; !exists {
+			changes.Removed = append(changes.Removed, id)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Retuned)
+	return changes
+}
+
+// sameConnection reports whether two configs would dial the same database,
+// and replicas, with the same credentials.
+func sameConnection(a, b DatabaseConfig) bool {
+	return a.Driver == b.Driver && a.BuildDSN() == b.BuildDSN() &&
+		slices.Equal(a.replicaDSNs(), b.replicaDSNs())
+}
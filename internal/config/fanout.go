@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// AllDatabases is the database id of the fan-out routes, /api/_all/...,
+// which no configured database may use.
+const AllDatabases = "_all"
+
+const (
+	// DefaultFanOutTimeout limits each database's part of a fan-out request.
+	DefaultFanOutTimeout = 5 * time.Second
+	// DefaultFanOutMaxConcurrency is how many databases a fan-out request
+	// queries at once.
+	DefaultFanOutMaxConcurrency = 4
+)
+
+// FanOutConfig bounds the /api/_all requests, which query every database.
+type FanOutConfig struct {
+	Timeout        time.Duration `yaml:"timeout"`
+	MaxConcurrency int           `yaml:"max_concurrency"`
+}
+
+func (c *FanOutConfig) applyDefaults() {
+	if c.Timeout == 0 {
+		c.Timeout = DefaultFanOutTimeout
+	}
+	if c.MaxConcurrency == 0 {
+		c.MaxConcurrency = DefaultFanOutMaxConcurrency
+	}
+}
+
+// Validate rejects negative bounds and a database named like the fan-out
+// routes.
+func (c FanOutConfig) Validate(databases map[string]DatabaseConfig) error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("fan_out: timeout must not be negative")
+	}
+	if c.MaxConcurrency < 0 {
+		return fmt.Errorf("fan_out: max_concurrency must not be negative")
+	}
+	if _, exists := databases[AllDatabases]; exists {
+		return fmt.Errorf("database id %q is reserved for fan-out requests", AllDatabases)
+	}
+	return nil
+}
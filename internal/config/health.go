@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHealthTimeout is how long a readiness check waits for all
+	// databases together.
+	DefaultHealthTimeout = 2 * time.Second
+	// DefaultHealthCacheTTL is how long a readiness result is reused.
+	DefaultHealthCacheTTL = 5 * time.Second
+)
+
+// HealthConfig controls the readiness probe.
+type HealthConfig struct {
+	Timeout  time.Duration `yaml:"timeout"`
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// Database ids the service is not ready without; the others only make
+	// it degraded. With none listed it is ready while any database is up.
+	Critical []string `yaml:"critical"`
+}
+
+func (c *HealthConfig) applyDefaults() {
+	if c.Timeout == 0 {
+		c.Timeout = DefaultHealthTimeout
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = DefaultHealthCacheTTL
+	}
+	for i, id := range c.Critical {
+		c.Critical[i] = strings.ToLower(id)
+	}
+}
+
+// Validate checks that the critical databases are configured.
+func (c HealthConfig) Validate(databases map[string]DatabaseConfig) error {
+	if c.Timeout < 0 || c.CacheTTL < 0 {
+		return fmt.Errorf("health: durations must not be negative")
+	}
+	for _, id := range c.Critical {
+		if _, exists := databases[id]; !exists {
+			return fmt.Errorf("health: critical database %q is not configured", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMigrationLockTimeout is how long a migration waits for another
+// process to finish migrating the same database.
+const DefaultMigrationLockTimeout = time.Minute
+
+// MigrationsConfig controls the embedded schema migrations, which are also
+// run by the migrate subcommand.
+type MigrationsConfig struct {
+	// Apply pending migrations before serving
+	OnStartup bool `yaml:"on_startup"`
+	// Database ids to migrate; empty migrates every configured database
+	Databases   []string      `yaml:"databases"`
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+}
+
+func (c *MigrationsConfig) applyDefaults() {
+	if c.LockTimeout == 0 {
+		c.LockTimeout = DefaultMigrationLockTimeout
+	}
+	for i, id := range c.Databases {
+		c.Databases[i] = strings.ToLower(id)
+	}
+}
+
+// Validate checks that the selected databases are configured.
+func (c MigrationsConfig) Validate(databases map[string]DatabaseConfig) error {
+	if c.LockTimeout < 0 {
+		return fmt.Errorf("migrations: lock_timeout must not be negative")
+	}
+	for _, id := range c.Databases {
+		if _, exists := databases[id]; !exists {
+			return fmt.Errorf("migrations: database %q is not configured", id)
+		}
+	}
+	return nil
+}
+
+// MigrationDatabaseIDs returns the ids of the databases to migrate, sorted.
+func (c *Config) MigrationDatabaseIDs() []string {
+	if len(c.Migrations.Databases) == 0 {
+		return c.DatabaseIDs()
+	}
+	ids := append([]string(nil), c.Migrations.Databases...)
+	sort.Strings(ids)
+	return ids
+}
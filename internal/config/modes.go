@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Database modes
+const (
+	// Reads and writes are served
+	ModeNormal = "normal"
+	// Reads are served, writes rejected
+	ModeReadOnly = "read_only"
+	// Nothing is served
+	ModeMaintenance = "maintenance"
+)
+
+// DatabaseModes are the supported database modes.
+var DatabaseModes = []string{ModeNormal, ModeReadOnly, ModeMaintenance}
+
+// ModeConfig takes a database out of normal service, e.g. during a patch
+// window.
+type ModeConfig struct {
+	Name string `yaml:"mode,omitempty"`
+	// Expected end of the window, told to clients in Retry-After
+	Until time.Time `yaml:"mode_until,omitempty"`
+	// Shown to rejected clients instead of the default message
+	Message string `yaml:"mode_message,omitempty"`
+}
+
+func (m *ModeConfig) applyDefaults() {
+	m.Name = strings.ToLower(m.Name)
+	if m.Name == "" {
+		m.Name = ModeNormal
+	}
+}
+
+// Validate rejects unknown modes.
+func (m ModeConfig) Validate() error {
+	for _, name := range DatabaseModes {
+		if m.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mode %q (expected one of %v)", m.Name, DatabaseModes)
+}
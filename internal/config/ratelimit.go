@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RateLimitGroups are the route groups rate limits can be set for.
+var RateLimitGroups = []string{"api", "admin"}
+
+// RateLimitConfig limits one route group. Limits left at zero are off.
+type RateLimitConfig struct {
+	PerClient   LimitConfig `yaml:"per_client"`
+	PerDatabase LimitConfig `yaml:"per_database"`
+}
+
+// LimitConfig is a token bucket: up to Burst requests at once, refilled at
+// Rate requests per second. Burst defaults to the rate, rounded up.
+type LimitConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+func (l *LimitConfig) applyDefaults() {
+	if l.Rate > 0 && l.Burst == 0 {
+		l.Burst = int(math.Ceil(l.Rate))
+	}
+}
+
+func (l LimitConfig) validate(name string) error {
+	if l.Rate < 0 || l.Burst < 0 {
+		return fmt.Errorf("%s: rate and burst must not be negative", name)
+	}
+	return nil
+}
+
+// validateRateLimits rejects unknown groups and negative limits.
+func validateRateLimits(limits map[string]RateLimitConfig) error {
+	groups := make([]string, 0, len(limits))
+	for group := range limits {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		known := false
+		for _, g := range RateLimitGroups {
+			known = known || g == group
+		}
+		if !known {
+			return fmt.Errorf("rate_limits: unknown route group %q (expected one of %v)", group, RateLimitGroups)
+		}
+		limit := limits[group]
+		if err := limit.PerClient.validate("rate_limits." + group + ".per_client"); err != nil {
+			return err
+		}
+		if err := limit.PerDatabase.validate("rate_limits." + group + ".per_database"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ReplicaConfig is a read replica of a database, reached with the
+// database's driver and pool settings.
+type ReplicaConfig struct {
+	DSN    string `yaml:"dsn,omitempty"`
+	DSNRef string `yaml:"dsn_ref,omitempty"`
+}
+
+// ReadsConfig decides which replica, if any, serves the reads of a
+// database.
+type ReadsConfig struct {
+	// Replicas further behind the primary are skipped; 0 accepts any lag
+	MaxStaleness time.Duration `yaml:"max_staleness"`
+	// Where reads go when no replica is usable: "primary" or "fail"
+	Fallback string `yaml:"fallback"`
+}
+
+// Read fallbacks
+const (
+	FallbackPrimary = "primary"
+	FallbackFail    = "fail"
+)
+
+// validateReplicas checks the replica settings of a database.
+func (dc DatabaseConfig) validateReplicas() error {
+	for i, replica := range dc.Replicas {
+		if replica.DSN == "" {
+			return fmt.Errorf("replicas[%d]: missing dsn", i)
+		}
+	}
+	if dc.Reads.MaxStaleness < 0 {
+		return fmt.Errorf("reads: max_staleness must not be negative")
+	}
+	if dc.Reads.Fallback != FallbackPrimary && dc.Reads.Fallback != FallbackFail {
+		return fmt.Errorf("reads: unknown fallback %q (expected %q or %q)", dc.Reads.Fallback, FallbackPrimary, FallbackFail)
+	}
+	return nil
+}
+
+// resolveReplicaSecrets fetches the referenced replica DSNs.
+func (dc *DatabaseConfig) resolveReplicaSecrets(ctx context.Context, secrets SecretResolver) error {
+	replicas := slices.Clone(dc.Replicas)
+	for i, replica := range replicas {
+		if replica.DSNRef == "" {
+			continue
+		}
+		if secrets == nil {
+			return fmt.Errorf("database %q: secret references configured but no secret provider available", dc.ID)
+		}
+		dsn, err := secrets.Resolve(ctx, replica.DSNRef)
+		if err != nil {
+			return fmt.Errorf("database %q: replicas[%d]: dsn: %w", dc.ID, i, err)
+		}
+		replicas[i].DSN = dsn
+	}
+	dc.Replicas = replicas
+	return nil
+}
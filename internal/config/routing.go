@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Target is what a {database_id} in a URL names: a database, an alias of
+// one, or a group of them.
+type Target struct {
+	// Canonical database ids: one, or the members of a group
+	IDs   []string
+	Group bool
+}
+
+// applyRoutingEnv overlays DB_ALIAS_<NAME>=<id> and
+// DB_GROUP_<NAME>=<id>,<id>,... variables.
+func applyRoutingEnv(cfg *Config, env map[string]string) {
+	for key, value := range env {
+		if name, ok := strings.CutPrefix(key, "DB_ALIAS_"); ok && name != "" && value != "" {
+			if cfg.Aliases == nil {
+				cfg.Aliases = make(map[string]string)
+			}
+			cfg.Aliases[name] = value
+		}
+		if name, ok := strings.CutPrefix(key, "DB_GROUP_"); ok && name != "" && value != "" {
+			if cfg.Groups == nil {
+				cfg.Groups = make(map[string][]string)
+			}
+			var members []string
+			for _, id := range strings.Split(value, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					members = append(members, id)
+				}
+			}
+			cfg.Groups[name] = members
+		}
+	}
+}
+
+// normaliseRouting lower-cases alias and group names and their targets,
+// like database ids.
+func (c *Config) normaliseRouting() {
+	aliases := make(map[string]string, len(c.Aliases))
+	for name, id := range c.Aliases {
+		aliases[strings.ToLower(name)] = strings.ToLower(strings.TrimSpace(id))
+	}
+	c.Aliases = aliases
+
+	groups := make(map[string][]string, len(c.Groups))
+	for name, members := range c.Groups {
+		lowered := make([]string, len(members))
+		for i, id := range members {
+			lowered[i] = strings.ToLower(strings.TrimSpace(id))
+		}
+		groups[strings.ToLower(name)] = lowered
+	}
+	c.Groups = groups
+}
+
+// validateRouting checks that alias and group names are unique and that
+// they name configured databases. Group members may be aliases; they are
+// replaced by the database they stand for.
+func (c *Config) validateRouting() error {
+	reserved := func(name string) error {
+		if name == AllDatabases {
+			return fmt.Errorf("%q is reserved for fan-out requests", name)
+		}
+		if _, exists := c.Databases[name]; exists {
+			return fmt.Errorf("%q is already a database id", name)
+		}
+		return nil
+	}
+
+	for name, id := range c.Aliases {
+		if err := reserved(name); err != nil {
+			return fmt.Errorf("aliases: %w", err)
+		}
+		if _, exists := c.Databases[id]; !exists {
+			return fmt.Errorf("aliases: %s: database %q is not configured", name, id)
+		}
+	}
+	for name, members := range c.Groups {
+		if err := reserved(name); err != nil {
+			return fmt.Errorf("groups: %w", err)
+		}
+		if _, exists := c.Aliases[name]; exists {
+			return fmt.Errorf("groups: %q is already an alias", name)
+		}
+		if len(members) == 0 {
+			return fmt.Errorf("groups: %s has no members", name)
+		}
+		ids := make([]string, 0, len(members))
+		for _, id := range members {
+			if canonical, isAlias := c.Aliases[id]; isAlias {
+				id = canonical
+			}
+			if _, exists := c.Databases[id]; !exists {
+				return fmt.Errorf("groups: %s: database %q is not configured", name, id)
+			}
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		c.Groups[name] = ids
+	}
+	return nil
+}
+
+// Resolve looks up a database id, alias or group name, as given in a URL.
+func (c *Config) Resolve(name string) (Target, bool) {
+	name = strings.ToLower(name)
+	if _, exists := c.Databases[name]; exists {
+		return Target{IDs: []string{name}}, true
+	}
+	if id, exists := c.Aliases[name]; exists {
+		return Target{IDs: []string{id}}, true
+	}
+	if members, exists := c.Groups[name]; exists {
+		return Target{IDs: members, Group: true}, true
+	}
+	return Target{}, false
+}
@@ -0,0 +1,54 @@
+package config
+
+import "fmt"
+
+// TracingExporters are the supported trace exporters.
+var TracingExporters = []string{"otlp", "stdout"}
+
+// DefaultServiceName identifies this service in traces.
+const DefaultServiceName = "go-chi"
+
+// TracingConfig configures OpenTelemetry tracing. Tracing is off unless an
+// exporter is set; incoming traceparent headers are honoured either way.
+type TracingConfig struct {
+	// "otlp" (OTLP over HTTP) or "stdout" (console, printed on stderr)
+	Exporter string `yaml:"exporter"`
+	// OTLP collector host:port. Empty uses OTEL_EXPORTER_OTLP_ENDPOINT,
+	// or localhost:4318
+	Endpoint string `yaml:"endpoint"`
+	// Send OTLP over plain HTTP, as to a collector on localhost
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"` // of new traces, 0-1; default 1
+}
+
+// Enabled reports whether spans are exported.
+func (c TracingConfig) Enabled() bool {
+	return c.Exporter != ""
+}
+
+func (c *TracingConfig) applyDefaults() {
+	if c.ServiceName == "" {
+		c.ServiceName = DefaultServiceName
+	}
+	if c.SampleRatio == 0 {
+		c.SampleRatio = 1
+	}
+}
+
+// Validate rejects unknown exporters and out of range sample ratios.
+func (c TracingConfig) Validate() error {
+	if c.Enabled() {
+		known := false
+		for _, e := range TracingExporters {
+			known = known || e == c.Exporter
+		}
+		if !known {
+			return fmt.Errorf("tracing: unknown exporter %q (expected one of %v)", c.Exporter, TracingExporters)
+		}
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing: sample_ratio must be between 0 and 1")
+	}
+	return nil
+}
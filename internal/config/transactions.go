@@ -0,0 +1,55 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Isolation levels of write transactions
+const (
+	IsolationReadCommitted = "read_committed"
+	IsolationSerializable  = "serializable"
+)
+
+// DefaultTransactionMaxAttempts is how many times a write request runs
+// when its transaction keeps failing to serialize.
+const DefaultTransactionMaxAttempts = 3
+
+// TransactionsConfig sets how write requests run in a transaction.
+type TransactionsConfig struct {
+	// "read_committed" (default) or "serializable"
+	Isolation string `yaml:"isolation"`
+	// Runs of a request, the first included, while it fails to serialize
+	MaxAttempts int `yaml:"max_attempts"`
+}
+
+func (c *TransactionsConfig) applyDefaults() {
+	c.Isolation = strings.ToLower(c.Isolation)
+	if c.Isolation == "" {
+		c.Isolation = IsolationReadCommitted
+	}
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = DefaultTransactionMaxAttempts
+	}
+}
+
+// Validate rejects unknown isolation levels and negative attempts.
+func (c TransactionsConfig) Validate() error {
+	if c.Isolation != IsolationReadCommitted && c.Isolation != IsolationSerializable {
+		return fmt.Errorf("transactions: unknown isolation %q (expected %q or %q)",
+			c.Isolation, IsolationReadCommitted, IsolationSerializable)
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("transactions: max_attempts must be at least 1")
+	}
+	return nil
+}
+
+// IsolationLevel returns the isolation as a database/sql level.
+func (c TransactionsConfig) IsolationLevel() sql.IsolationLevel {
+	if c.Isolation == IsolationSerializable {
+		return sql.LevelSerializable
+	}
+	return sql.LevelReadCommitted
+}
@@ -0,0 +1,72 @@
+// Package fanout runs one call against many databases concurrently and
+// collects every outcome, so that one slow or failing database does not
+// hide the results of the others.
+package fanout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Options bounds a fan-out.
+type Options struct {
+	// Calls running at once; 0 runs them all at once
+	MaxConcurrency int
+	// Limit on each call; 0 for none
+	Timeout time.Duration
+}
+
+// Result is the outcome of the call against one database.
+type Result[T any] struct {
+	DatabaseID string
+	Value      T
+	Err        error
+	Duration   time.Duration
+}
+
+// Run calls call once per database id, each under its own timeout, and
+// returns the results in the order of ids. Calls still waiting for a slot
+// when ctx ends fail with its error.
+func Run[T any](ctx context.Context, ids []string, opts Options, call func(ctx context.Context, dbID string) (T, error)) []Result[T] {
+	limit := opts.MaxConcurrency
+	if limit <= 0 || limit > len(ids) {
+		limit = len(ids)
+	}
+	slots := make(chan struct{}, limit)
+
+	results := make([]Result[T], len(ids))
+	var wg sync.WaitGroup
+	for i, dbID := range ids {
+		results[i].DatabaseID = dbID
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *Result[T]) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			callCtx, cancel := ctx, context.CancelFunc(func() {})
+			if opts.Timeout > 0 {
+				callCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			}
+			defer cancel()
+
+			start := time.Now()
+			result.Value, result.Err = call(callCtx, result.DatabaseID)
+			result.Duration = time.Since(start)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,113 @@
+package fanout_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/fanout"
+)
+
+// ============================================================================
+// Run Tests
+// ============================================================================
+
+func TestRun_ResultsInOrder(t *testing.T) {
+	// Arrange
+	ids := []string{"sales", "hr", "finance"}
+	failure := errors.New("ORA-12541: no listener")
+
+	// Act
+	results := fanout.Run(context.Background(), ids, fanout.Options{}, func(ctx context.Context, dbID string) (int, error) {
+		if dbID == "hr" {
+			return 0, failure
+		}
+		return len(dbID), nil
+	})
+
+	// Assert
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for i, want := range []fanout.Result[int]{
+		{DatabaseID: "sales", Value: 5},
+		{DatabaseID: "hr", Err: failure},
+		{DatabaseID: "finance", Value: 7},
+	} {
+		got := results[i]
+		if got.DatabaseID != want.DatabaseID || got.Value != want.Value || !errors.Is(got.Err, want.Err) {
+			t.Errorf("result %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestRun_BoundsConcurrency(t *testing.T) {
+	// Arrange
+	var running, peak atomic.Int32
+	ids := []string{"a", "b", "c", "d", "e", "f"}
+
+	// Act
+	fanout.Run(context.Background(), ids, fanout.Options{MaxConcurrency: 2}, func(ctx context.Context, dbID string) (struct{}, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return struct{}{}, nil
+	})
+
+	// Assert
+	if got := peak.Load(); got != 2 {
+		t.Errorf("expected at most 2 calls at once, got %d", got)
+	}
+}
+
+func TestRun_TimeoutPerCall(t *testing.T) {
+	// Act
+	start := time.Now()
+	results := fanout.Run(context.Background(), []string{"slow", "fast"}, fanout.Options{Timeout: 20 * time.Millisecond},
+		func(ctx context.Context, dbID string) (string, error) {
+			if dbID == "slow" {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}
+			return "ok", nil
+		})
+
+	// Assert
+	if !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("expected the slow call to time out, got %v", results[0].Err)
+	}
+	if results[1].Err != nil || results[1].Value != "ok" {
+		t.Errorf("expected the fast call to succeed, got %+v", results[1])
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the fan-out to end with the timeout, took %v", elapsed)
+	}
+}
+
+func TestRun_CancelledWhileWaiting(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Act: the first call cancels the fan-out while holding the only slot
+	results := fanout.Run(ctx, []string{"a", "b"}, fanout.Options{MaxConcurrency: 1}, func(ctx context.Context, dbID string) (int, error) {
+		cancel()
+		return 1, nil
+	})
+
+	// Assert
+	if results[0].Err != nil {
+		t.Errorf("expected the running call to finish, got %v", results[0].Err)
+	}
+	if !errors.Is(results[1].Err, context.Canceled) {
+		t.Errorf("expected the waiting call to be cancelled, got %v", results[1].Err)
+	}
+}
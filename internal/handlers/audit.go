@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/logging"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// TransactionHistory lists the changes made to a transaction, oldest first.
+func (h *CryptoHandlers) TransactionHistory(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	repository := MustGetRepo(r.Context())
+
+	id, ok := transactionID(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := repository.TransactionHistory(r.Context(), id)
+	if err == nil && len(history) == 0 {
+		// Transactions from before the audit trail have no history
+		_, err = repository.GetTransaction(r.Context(), id)
+	}
+	if errors.Is(err, repo.ErrNotFound) {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Error("failed to get transaction history", "id", id, "error", err)
+		http.Error(w, "Failed to get transaction history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"transactions_seq": id,
+		"history":          history,
+	})
+}
+
+// ListAudit lists the audit trail of the database, newest first, filtered
+// by the transaction_id, operation, actor, request_id, from and to
+// (YYYY-MM-DD, inclusive) query parameters.
+func (h *CryptoHandlers) ListAudit(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	repository := MustGetRepo(r.Context())
+	dbID, _ := GetDBID(r.Context())
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, pageSize := Pagination(r)
+
+	log.Info("listing audit trail",
+		"database_id", dbID,
+		"page", page,
+		"page_size", pageSize)
+
+	entries, err := repository.ListAudit(r.Context(), filter, page, pageSize)
+	if err != nil {
+		log.Error("failed to list audit trail", "error", err)
+		http.Error(w, "Failed to list audit trail", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries":   entries,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// auditFilter parses the filters of ListAudit
+func auditFilter(r *http.Request) (repo.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := repo.AuditFilter{
+		Operation: strings.ToUpper(query.Get("operation")),
+		Actor:     query.Get("actor"),
+		RequestID: query.Get("request_id"),
+	}
+
+	if value := query.Get("transaction_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			return repo.AuditFilter{}, errors.New("Invalid transaction_id")
+		}
+		filter.TransactionID = id
+	}
+	if filter.Operation != "" && !slices.Contains(repo.AuditOperations, filter.Operation) {
+		return repo.AuditFilter{}, fmt.Errorf("Invalid operation (expected one of %s)",
+			strings.ToLower(strings.Join(repo.AuditOperations, ", ")))
+	}
+	if value := query.Get("from"); value != "" {
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return repo.AuditFilter{}, errors.New("Invalid from format (expected YYYY-MM-DD)")
+		}
+		filter.From = value
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return repo.AuditFilter{}, errors.New("Invalid to format (expected YYYY-MM-DD)")
+		}
+		filter.Until = to.AddDate(0, 0, 1).Format(time.DateOnly)
+	}
+	return filter, nil
+}
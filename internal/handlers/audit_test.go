@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/handlers"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// ============================================================================
+// TransactionHistory Tests
+// ============================================================================
+
+func TestTransactionHistory(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		audit          []repo.AuditEntry
+		stored         []repo.Transaction
+		expectedStatus int
+		expectedLen    int
+	}{
+		{
+			name:           "deleted transaction keeps its history",
+			id:             "1",
+			audit:          []repo.AuditEntry{{TransactionsSeq: 1, Operation: repo.AuditCreate}, {TransactionsSeq: 1, Operation: repo.AuditDelete}},
+			expectedStatus: http.StatusOK,
+			expectedLen:    2,
+		},
+		{
+			name:           "transaction from before the audit trail",
+			id:             "1",
+			stored:         []repo.Transaction{seedTransaction()},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown transaction",
+			id:             "2",
+			audit:          []repo.AuditEntry{{TransactionsSeq: 1, Operation: repo.AuditCreate}},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req, mockRepo := setupRequest("GET", "/crypto/transactions/"+tt.id+"/history", nil)
+			mockRepo.audit, mockRepo.transactions = tt.audit, tt.stored
+			req = withID(req, tt.id)
+			w := httptest.NewRecorder()
+
+			// Act
+			handlers.NewCryptoHandlers(nil).TransactionHistory(w, req)
+
+			// Assert
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var response struct {
+				History []repo.AuditEntry `json:"history"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.History) != tt.expectedLen {
+				t.Errorf("expected %d entries, got %+v", tt.expectedLen, response.History)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// ListAudit Tests
+// ============================================================================
+
+func TestListAudit_Filters(t *testing.T) {
+	// Arrange
+	req, mockRepo := setupRequest("GET",
+		"/audit?transaction_id=7&operation=update&actor=alice&request_id=host/abc-000001&from=2024-01-01&to=2024-01-31", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handlers.NewCryptoHandlers(nil).ListAudit(w, req)
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := repo.AuditFilter{
+		TransactionID: 7,
+		Operation:     repo.AuditUpdate,
+		Actor:         "alice",
+		RequestID:     "host/abc-000001",
+		From:          "2024-01-01",
+		Until:         "2024-02-01", // the day after to
+	}
+	if mockRepo.auditFilter != want {
+		t.Errorf("expected filter %+v, got %+v", want, mockRepo.auditFilter)
+	}
+}
+
+func TestListAudit_InvalidFilters(t *testing.T) {
+	for _, query := range []string{
+		"operation=rename",
+		"transaction_id=abc",
+		"from=01/01/2024",
+		"to=2024-13-01",
+	} {
+		t.Run(query, func(t *testing.T) {
+			// Arrange
+			req, _ := setupRequest("GET", "/audit?"+query, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			handlers.NewCryptoHandlers(nil).ListAudit(w, req)
+
+			// Assert
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
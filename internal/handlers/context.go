@@ -11,10 +11,50 @@ type contextKey string
 const (
 	RepoContextKey contextKey = "repository"
 	DBIDContextKey contextKey = "database_id"
+	// Carries the *AfterCommit of a request run in a transaction
+	AfterCommitContextKey contextKey = "after_commit"
 )
 
-func GetRepo(ctx context.Context) (*repo.Repository, bool) {
-	repo, ok := ctx.Value(RepoContextKey).(*repo.Repository)
+// AfterCommit holds what a request does once its transaction commits, such
+// as counting the transactions it created.
+type AfterCommit struct {
+	fns []func()
+}
+
+// Run calls the functions added by OnCommit, in order.
+func (a *AfterCommit) Run() {
+	for _, fn := range a.fns {
+		fn()
+	}
+}
+
+// OnCommit calls fn once the changes of the request are committed, or
+// straight away when the request does not run in a transaction.
+func OnCommit(ctx context.Context, fn func()) {
+	if a, ok := ctx.Value(AfterCommitContextKey).(*AfterCommit); ok {
+		a.fns = append(a.fns, fn)
+		return
+	}
+	fn()
+}
+
+// CryptoRepository is the subset of *repo.Repository the crypto handlers use.
+// Depending on the interface rather than the concrete type lets tests inject
+// a mock repository through the request context.
+type CryptoRepository interface {
+	ListTransactions(ctx context.Context, page, pageSize int) ([]repo.Transaction, error)
+	CreateTransaction(ctx context.Context, t repo.Transaction) error
+	ImportTransaction(ctx context.Context, t repo.Transaction) error
+	GetTransaction(ctx context.Context, id int) (repo.Transaction, error)
+	UpdateTransaction(ctx context.Context, t repo.Transaction) error
+	DeleteTransaction(ctx context.Context, id int) error
+	Holdings(ctx context.Context) ([]repo.Holding, error)
+	TransactionHistory(ctx context.Context, id int) ([]repo.AuditEntry, error)
+	ListAudit(ctx context.Context, filter repo.AuditFilter, page, pageSize int) ([]repo.AuditEntry, error)
+}
+
+func GetRepo(ctx context.Context) (CryptoRepository, bool) {
+	repo, ok := ctx.Value(RepoContextKey).(CryptoRepository)
 	return repo, ok
 }
 
@@ -23,7 +63,7 @@ func GetDBID(ctx context.Context) (string, bool) {
 	return dbID, ok
 }
 
-func MustGetRepo(ctx context.Context) *repo.Repository {
+func MustGetRepo(ctx context.Context) CryptoRepository {
 	repo, ok := GetRepo(ctx)
 	if !ok {
 		panic("repository not found in context")
@@ -1,20 +1,40 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"log/slog"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/auth"
+	"github.com/hotbrandon/go-chi/internal/logging"
 	"github.com/hotbrandon/go-chi/internal/repo"
 )
 
+// Recorder receives domain events for metrics.
+type Recorder interface {
+	TransactionsCreated(dbID, exchange string, n int)
+}
+
 type CryptoHandlers struct {
+	recorder Recorder
 }
 
-func NewCryptoHandlers() *CryptoHandlers {
-	return &CryptoHandlers{}
+// NewCryptoHandlers returns the crypto handlers. recorder may be nil.
+func NewCryptoHandlers(recorder Recorder) *CryptoHandlers {
+	return &CryptoHandlers{recorder: recorder}
+}
+
+// recordCreated reports n transactions created on exchange, once they are
+// committed
+func (h *CryptoHandlers) recordCreated(ctx context.Context, dbID, exchange string, n int) {
+	if h.recorder != nil {
+		OnCommit(ctx, func() { h.recorder.TransactionsCreated(dbID, exchange, n) })
+	}
 }
 
 type CreateTransactionRequest struct {
@@ -28,7 +48,25 @@ type CreateTransactionRequest struct {
 	Notes           string  `json:"notes,omitempty"`
 }
 
+// toTransaction validates the request and converts it to a repo.Transaction
+func (req CreateTransactionRequest) toTransaction() (repo.Transaction, error) {
+	if _, err := time.Parse(time.DateOnly, req.TransactionDate); err != nil {
+		return repo.Transaction{}, errors.New("Invalid transaction_date format (expected YYYY-MM-DD)")
+	}
+	return repo.Transaction{
+		CoinSymbol:      req.CoinSymbol,
+		TransactionType: req.TransactionType,
+		Quantity:        req.Quantity,
+		PricePerUnit:    req.PricePerUnit,
+		TotalCost:       req.TotalCost,
+		TransactionDate: req.TransactionDate,
+		Exchange:        req.Exchange,
+		Notes:           stringToPtr(req.Notes),
+	}, nil
+}
+
 func (h *CryptoHandlers) CreateTransaction(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
 	repository := MustGetRepo(r.Context())
 	dbID, _ := GetDBID(r.Context())
 
@@ -38,31 +76,23 @@ func (h *CryptoHandlers) CreateTransaction(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	slog.Info("creating transaction",
+	log.Info("creating transaction",
 		"database_id", dbID,
+		"subject", auth.SubjectFromContext(r.Context()),
 		"coin", req.CoinSymbol)
 
-	if _, err := time.Parse(time.DateOnly, req.TransactionDate); err != nil {
-		http.Error(w, "Invalid transaction_date format (expected YYYY-MM-DD)", http.StatusBadRequest)
+	t, err := req.toTransaction()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	t := repo.Transaction{
-		CoinSymbol:      req.CoinSymbol,
-		TransactionType: req.TransactionType,
-		Quantity:        req.Quantity,
-		PricePerUnit:    req.PricePerUnit,
-		TotalCost:       req.TotalCost,
-		TransactionDate: req.TransactionDate,
-		Exchange:        req.Exchange,
-		Notes:           stringToPtr(req.Notes),
-	}
-
 	if err := repository.CreateTransaction(r.Context(), t); err != nil {
-		slog.Error("failed to create transaction", "error", err)
+		log.Error("failed to create transaction", "error", err)
 		http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
 		return
 	}
+	h.recordCreated(r.Context(), dbID, t.Exchange, 1)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -71,30 +101,20 @@ func (h *CryptoHandlers) CreateTransaction(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *CryptoHandlers) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
 	repository := MustGetRepo(r.Context())
 	dbID, _ := GetDBID(r.Context())
 
-	page, err := strconv.Atoi(r.URL.Query().Get("page"))
-	if err != nil || page < 1 {
-		page = 1
-	}
-
-	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
-	if err != nil || pageSize < 1 {
-		pageSize = 20
-	}
-	if pageSize > 100 {
-		pageSize = 100
-	}
+	page, pageSize := Pagination(r)
 
-	slog.Info("listing transactions",
+	log.Info("listing transactions",
 		"database_id", dbID,
 		"page", page,
 		"page_size", pageSize)
 
 	transactions, err := repository.ListTransactions(r.Context(), page, pageSize)
 	if err != nil {
-		slog.Error("failed to list transactions", "error", err)
+		log.Error("failed to list transactions", "error", err)
 		http.Error(w, "Failed to list transactions", http.StatusInternalServerError)
 		return
 	}
@@ -109,13 +129,202 @@ func (h *CryptoHandlers) ListTransactions(w http.ResponseWriter, r *http.Request
 }
 
 func (h *CryptoHandlers) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	repository := MustGetRepo(r.Context())
+
+	id, ok := transactionID(w, r)
+	if !ok {
+		return
+	}
+
+	t, err := repository.GetTransaction(r.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Error("failed to get transaction", "id", id, "error", err)
+		http.Error(w, "Failed to get transaction", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNotImplemented)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(t)
+}
+
+func (h *CryptoHandlers) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	repository := MustGetRepo(r.Context())
+	dbID, _ := GetDBID(r.Context())
+
+	id, ok := transactionID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	t, err := req.toTransaction()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	t.TransactionsSeq = id
+
+	log.Info("updating transaction",
+		"database_id", dbID,
+		"subject", auth.SubjectFromContext(r.Context()),
+		"id", id)
+
+	err = repository.UpdateTransaction(r.Context(), t)
+	if errors.Is(err, repo.ErrNotFound) {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Error("failed to update transaction", "id", id, "error", err)
+		http.Error(w, "Failed to update transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
-		"error": "Not implemented yet",
+		"status": "updated",
+	})
+}
+
+func (h *CryptoHandlers) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	repository := MustGetRepo(r.Context())
+	dbID, _ := GetDBID(r.Context())
+
+	id, ok := transactionID(w, r)
+	if !ok {
+		return
+	}
+
+	log.Info("deleting transaction",
+		"database_id", dbID,
+		"subject", auth.SubjectFromContext(r.Context()),
+		"id", id)
+
+	err := repository.DeleteTransaction(r.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Error("failed to delete transaction", "id", id, "error", err)
+		http.Error(w, "Failed to delete transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Holdings reports the position in each coin held in the database.
+func (h *CryptoHandlers) Holdings(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	repository := MustGetRepo(r.Context())
+
+	holdings, err := repository.Holdings(r.Context())
+	if err != nil {
+		log.Error("failed to compute holdings", "error", err)
+		http.Error(w, "Failed to compute holdings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"holdings": holdings,
 	})
 }
 
+// ImportTransactions loads transactions from a CSV body (see
+// repo.ReadTransactionsCSV). Every row is validated before any is inserted,
+// and the rows are only counted as created once all are, as the import
+// runs in a transaction that a failing row rolls back.
+func (h *CryptoHandlers) ImportTransactions(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	repository := MustGetRepo(r.Context())
+	dbID, _ := GetDBID(r.Context())
+
+	transactions, err := repo.ReadTransactionsCSV(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for i, t := range transactions {
+		if _, err := time.Parse(time.DateOnly, t.TransactionDate); err != nil {
+			http.Error(w, fmt.Sprintf("Row %d: invalid transaction_date format (expected YYYY-MM-DD)", i+1),
+				http.StatusBadRequest)
+			return
+		}
+	}
+
+	log.Info("importing transactions",
+		"database_id", dbID,
+		"subject", auth.SubjectFromContext(r.Context()),
+		"rows", len(transactions))
+
+	for i, t := range transactions {
+		if err := repository.ImportTransaction(r.Context(), t); err != nil {
+			log.Error("failed to import transaction",
+				"row", i+1,
+				"error", err)
+			http.Error(w, fmt.Sprintf("Failed to import row %d (nothing imported)", i+1),
+				http.StatusInternalServerError)
+			return
+		}
+	}
+	for _, t := range transactions {
+		h.recordCreated(r.Context(), dbID, t.Exchange, 1)
+	}
+	imported := len(transactions)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "imported",
+		"imported": imported,
+	})
+}
+
+// Pagination parses the page (default 1) and page_size (default 20, at most
+// 100) query parameters
+func Pagination(r *http.Request) (page, pageSize int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err = strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	return page, pageSize
+}
+
+// transactionID parses the {id} URL parameter, responding 400 if invalid
+func transactionID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		http.Error(w, "Invalid transaction id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
 func stringToPtr(s string) *string {
 	if s == "" {
 		return nil
@@ -51,8 +51,8 @@ func TestIntegration_CreateAndListTransactions(t *testing.T) {
 	defer db.Close()
 	defer cleanupTestData(t, db)
 
-	repository := repo.New(db)
-	handler := handlers.NewCryptoHandlers()
+	repository := repo.New(db, repo.Oracle)
+	handler := handlers.NewCryptoHandlers(nil)
 
 	// Test 1: Create a transaction
 	t.Run("create transaction", func(t *testing.T) {
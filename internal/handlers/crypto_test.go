@@ -5,10 +5,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/hotbrandon/go-chi/internal/handlers"
 	"github.com/hotbrandon/go-chi/internal/repo"
 )
@@ -20,6 +23,8 @@ import (
 type MockRepository struct {
 	// Store test data
 	transactions []repo.Transaction
+	audit        []repo.AuditEntry
+	auditFilter  repo.AuditFilter // Last filter passed to ListAudit
 	createError  error
 	listError    error
 }
@@ -36,6 +41,10 @@ func (m *MockRepository) CreateTransaction(ctx context.Context, t repo.Transacti
 	return nil
 }
 
+func (m *MockRepository) ImportTransaction(ctx context.Context, t repo.Transaction) error {
+	return m.CreateTransaction(ctx, t)
+}
+
 func (m *MockRepository) ListTransactions(ctx context.Context, page, pageSize int) ([]repo.Transaction, error) {
 	if m.listError != nil {
 		return nil, m.listError
@@ -43,6 +52,65 @@ func (m *MockRepository) ListTransactions(ctx context.Context, page, pageSize in
 	return m.transactions, nil
 }
 
+func (m *MockRepository) GetTransaction(ctx context.Context, id int) (repo.Transaction, error) {
+	for _, t := range m.transactions {
+		if t.TransactionsSeq == id {
+			return t, nil
+		}
+	}
+	return repo.Transaction{}, repo.ErrNotFound
+}
+
+func (m *MockRepository) UpdateTransaction(ctx context.Context, t repo.Transaction) error {
+	for i, existing := range m.transactions {
+		if existing.TransactionsSeq == t.TransactionsSeq {
+			t.CreatedAt = existing.CreatedAt
+			m.transactions[i] = t
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+func (m *MockRepository) DeleteTransaction(ctx context.Context, id int) error {
+	for i, t := range m.transactions {
+		if t.TransactionsSeq == id {
+			m.transactions = append(m.transactions[:i], m.transactions[i+1:]...)
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+func (m *MockRepository) Holdings(ctx context.Context) ([]repo.Holding, error) {
+	if m.listError != nil {
+		return nil, m.listError
+	}
+	holdings := []repo.Holding{}
+	for _, t := range m.transactions {
+		holdings = append(holdings, repo.Holding{CoinSymbol: t.CoinSymbol, Quantity: t.Quantity, Transactions: 1})
+	}
+	return holdings, nil
+}
+
+func (m *MockRepository) TransactionHistory(ctx context.Context, id int) ([]repo.AuditEntry, error) {
+	history := []repo.AuditEntry{}
+	for _, e := range m.audit {
+		if e.TransactionsSeq == id {
+			history = append(history, e)
+		}
+	}
+	return history, nil
+}
+
+func (m *MockRepository) ListAudit(ctx context.Context, filter repo.AuditFilter, page, pageSize int) ([]repo.AuditEntry, error) {
+	if m.listError != nil {
+		return nil, m.listError
+	}
+	m.auditFilter = filter
+	return m.audit, nil
+}
+
 // ============================================================================
 // Test Helpers
 // ============================================================================
@@ -72,6 +140,28 @@ func setupRequest(method, url string, body interface{}) (*http.Request, *MockRep
 	return req, mockRepo
 }
 
+// withID sets the {id} URL parameter the way the chi router would
+func withID(req *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// seedTransaction returns a stored transaction for update/delete tests
+func seedTransaction() repo.Transaction {
+	return repo.Transaction{
+		TransactionsSeq: 1,
+		CoinSymbol:      "BTC",
+		TransactionType: "B",
+		Quantity:        0.5,
+		PricePerUnit:    50000.00,
+		TotalCost:       25000.00,
+		TransactionDate: "2024-01-15T00:00:00",
+		Exchange:        "BN",
+		CreatedAt:       "2024-01-15T10:30:00",
+	}
+}
+
 // ============================================================================
 // CreateTransaction Tests
 // ============================================================================
@@ -93,7 +183,7 @@ func TestCreateTransaction_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Act: Call the handler
-	handler := handlers.NewCryptoHandlers()
+	handler := handlers.NewCryptoHandlers(nil)
 	handler.CreateTransaction(w, req)
 
 	// Assert: Check response
@@ -128,7 +218,7 @@ func TestCreateTransaction_InvalidJSON(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Act
-	handler := handlers.NewCryptoHandlers()
+	handler := handlers.NewCryptoHandlers(nil)
 	handler.CreateTransaction(w, req)
 
 	// Assert
@@ -153,7 +243,7 @@ func TestCreateTransaction_InvalidDate(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Act
-	handler := handlers.NewCryptoHandlers()
+	handler := handlers.NewCryptoHandlers(nil)
 	handler.CreateTransaction(w, req)
 
 	// Assert
@@ -182,7 +272,7 @@ func TestCreateTransaction_RepositoryError(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Act
-	handler := handlers.NewCryptoHandlers()
+	handler := handlers.NewCryptoHandlers(nil)
 	handler.CreateTransaction(w, req)
 
 	// Assert
@@ -227,7 +317,7 @@ func TestListTransactions_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Act
-	handler := handlers.NewCryptoHandlers()
+	handler := handlers.NewCryptoHandlers(nil)
 	handler.ListTransactions(w, req)
 
 	// Assert
@@ -258,7 +348,7 @@ func TestListTransactions_Pagination(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Act
-	handler := handlers.NewCryptoHandlers()
+	handler := handlers.NewCryptoHandlers(nil)
 	handler.ListTransactions(w, req)
 
 	// Assert
@@ -284,7 +374,7 @@ func TestListTransactions_DefaultPagination(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Act
-	handler := handlers.NewCryptoHandlers()
+	handler := handlers.NewCryptoHandlers(nil)
 	handler.ListTransactions(w, req)
 
 	// Assert
@@ -304,6 +394,199 @@ func TestListTransactions_DefaultPagination(t *testing.T) {
 	}
 }
 
+// ============================================================================
+// GetTransaction / UpdateTransaction / DeleteTransaction Tests
+// ============================================================================
+
+func TestGetTransaction(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{"existing", "1", http.StatusOK},
+		{"missing", "42", http.StatusNotFound},
+		{"invalid id", "abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req, mockRepo := setupRequest("GET", "/crypto/transactions/"+tt.id, nil)
+			mockRepo.transactions = []repo.Transaction{seedTransaction()}
+			w := httptest.NewRecorder()
+
+			// Act
+			handlers.NewCryptoHandlers(nil).GetTransaction(w, withID(req, tt.id))
+
+			// Assert
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateTransaction_Success(t *testing.T) {
+	// Arrange
+	payload := handlers.CreateTransactionRequest{
+		CoinSymbol:      "BTC",
+		TransactionType: "S",
+		Quantity:        0.25,
+		PricePerUnit:    60000.00,
+		TotalCost:       15000.00,
+		TransactionDate: "2024-02-01",
+		Exchange:        "OK",
+	}
+	req, mockRepo := setupRequest("PUT", "/crypto/transactions/1", payload)
+	mockRepo.transactions = []repo.Transaction{seedTransaction()}
+	w := httptest.NewRecorder()
+
+	// Act
+	handlers.NewCryptoHandlers(nil).UpdateTransaction(w, withID(req, "1"))
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	updated := mockRepo.transactions[0]
+	if updated.TransactionType != "S" || updated.Quantity != 0.25 || updated.TransactionsSeq != 1 {
+		t.Errorf("expected transaction 1 to be updated, got %+v", updated)
+	}
+}
+
+func TestUpdateTransaction_NotFound(t *testing.T) {
+	// Arrange
+	payload := handlers.CreateTransactionRequest{TransactionDate: "2024-02-01"}
+	req, _ := setupRequest("PUT", "/crypto/transactions/42", payload)
+	w := httptest.NewRecorder()
+
+	// Act
+	handlers.NewCryptoHandlers(nil).UpdateTransaction(w, withID(req, "42"))
+
+	// Assert
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDeleteTransaction(t *testing.T) {
+	// Arrange
+	req, mockRepo := setupRequest("DELETE", "/crypto/transactions/1", nil)
+	mockRepo.transactions = []repo.Transaction{seedTransaction()}
+	w := httptest.NewRecorder()
+
+	// Act
+	handlers.NewCryptoHandlers(nil).DeleteTransaction(w, withID(req, "1"))
+
+	// Assert
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if len(mockRepo.transactions) != 0 {
+		t.Errorf("expected transaction to be deleted, %d left", len(mockRepo.transactions))
+	}
+
+	// Deleting again is a 404
+	w = httptest.NewRecorder()
+	handlers.NewCryptoHandlers(nil).DeleteTransaction(w, withID(req, "1"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHoldings(t *testing.T) {
+	// Arrange
+	req, mockRepo := setupRequest("GET", "/crypto/holdings", nil)
+	mockRepo.transactions = []repo.Transaction{seedTransaction()}
+	w := httptest.NewRecorder()
+
+	// Act
+	handlers.NewCryptoHandlers(nil).Holdings(w, req)
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Holdings []repo.Holding `json:"holdings"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Holdings) != 1 || response.Holdings[0].CoinSymbol != "BTC" {
+		t.Errorf("expected the BTC holding, got %+v", response.Holdings)
+	}
+
+	// Repository failures are a 500
+	mockRepo.listError = io.ErrUnexpectedEOF
+	w = httptest.NewRecorder()
+	handlers.NewCryptoHandlers(nil).Holdings(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+// ============================================================================
+// ImportTransactions Tests
+// ============================================================================
+
+func TestImportTransactions(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedRows   int
+	}{
+		{
+			name: "valid csv",
+			body: "coin_symbol,transaction_type,quantity,price_per_unit,total_cost,transaction_date,exchange,notes\n" +
+				"BTC,B,0.5,50000,25000,2024-01-15,BN,first\n" +
+				"ETH,S,2,3000,6000,2024-01-16T00:00:00,OK,\n",
+			expectedStatus: http.StatusCreated,
+			expectedRows:   2,
+		},
+		{
+			name:           "missing column",
+			body:           "coin_symbol,quantity\nBTC,1\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid number",
+			body: "coin_symbol,transaction_type,quantity,price_per_unit,total_cost,transaction_date,exchange\n" +
+				"BTC,B,lots,50000,25000,2024-01-15,BN\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid date rejects the whole file",
+			body: "coin_symbol,transaction_type,quantity,price_per_unit,total_cost,transaction_date,exchange\n" +
+				"BTC,B,1,50000,50000,2024-01-15,BN\n" +
+				"BTC,B,1,50000,50000,15/01/2024,BN\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req, mockRepo := setupRequest("POST", "/crypto/transactions/import", nil)
+			req.Body = io.NopCloser(strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			handlers.NewCryptoHandlers(nil).ImportTransactions(w, req)
+
+			// Assert
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if len(mockRepo.transactions) != tt.expectedRows {
+				t.Errorf("expected %d imported rows, got %d", tt.expectedRows, len(mockRepo.transactions))
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Table-Driven Test Example
 // ============================================================================
@@ -366,7 +649,7 @@ func TestCreateTransaction_Validation(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			// Act
-			handler := handlers.NewCryptoHandlers()
+			handler := handlers.NewCryptoHandlers(nil)
 			handler.CreateTransaction(w, req)
 
 			// Assert
@@ -0,0 +1,136 @@
+// Package logging writes one structured access log record per request and
+// gives handlers a logger carrying the request's identifiers.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options control which requests are logged.
+type Options struct {
+	// Fraction of successful (below 400) requests logged, 0-1. Failed and
+	// slow requests are always logged.
+	SampleRate float64
+	// Requests taking at least this long are logged as slow; 0 disables
+	SlowThreshold time.Duration
+}
+
+type contextKey struct{}
+
+// request is the per-request state shared with the rest of the chain.
+// Handlers run on derived contexts, so attributes added there reach the
+// access log through this pointer.
+type request struct {
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// FromContext returns the request's logger, which carries its request id
+// and trace id, or the default logger outside a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if req, ok := ctx.Value(contextKey{}).(*request); ok {
+		return req.logger
+	}
+	return slog.Default()
+}
+
+// AddAttrs adds attributes to the request's access log record, such as the
+// user once authentication has identified them.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	if req, ok := ctx.Value(contextKey{}).(*request); ok {
+		req.mu.Lock()
+		req.attrs = append(req.attrs, attrs...)
+		req.mu.Unlock()
+	}
+}
+
+// Middleware logs every failed or slow request and a sample of the rest.
+// The options are read per request so that reloads apply immediately. It
+// must run after middleware.RequestID and middleware.RealIP, and after the
+// tracing middleware for trace ids to be logged.
+func Middleware(options func() Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestID := middleware.GetReqID(r.Context())
+
+			logger := slog.Default().With("request_id", requestID)
+			traceID := ""
+			if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+				traceID = sc.TraceID().String()
+				logger = logger.With("trace_id", traceID)
+			}
+			req := &request{logger: logger}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), contextKey{}, req)))
+
+			latency := time.Since(start)
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			opts := options()
+			slow := opts.SlowThreshold > 0 && latency >= opts.SlowThreshold
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest || slow:
+				level = slog.LevelWarn
+			case rand.Float64() >= opts.SampleRate:
+				return
+			}
+
+			attrs := []slog.Attr{
+				slog.String("request_id", requestID),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", routePattern(r)),
+				slog.String("database_id", chi.URLParam(r, "database_id")),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+				slog.String("client_ip", clientIP(r)),
+				slog.Bool("slow", slow),
+			}
+			if traceID != "" {
+				attrs = append(attrs, slog.String("trace_id", traceID))
+			}
+			req.mu.Lock()
+			attrs = append(attrs, req.attrs...)
+			req.mu.Unlock()
+
+			slog.Default().LogAttrs(r.Context(), level, "request completed", attrs...)
+		})
+	}
+}
+
+// routePattern returns the matched chi route, available once routing is done
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
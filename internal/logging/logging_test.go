@@ -0,0 +1,162 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/hotbrandon/go-chi/internal/logging"
+)
+
+// captureLogs sends the default logger to a buffer for the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// records decodes the JSON log lines with the given message
+func records(t *testing.T, buf *bytes.Buffer, msg string) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if record["msg"] == msg {
+			out = append(out, record)
+		}
+	}
+	return out
+}
+
+// newRouter mounts a few routes behind the access log
+func newRouter(opts logging.Options) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(logging.Middleware(func() logging.Options { return opts }))
+	r.Route("/api/{database_id}", func(r chi.Router) {
+		r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+			logging.AddAttrs(r.Context(), slog.String("user", "api_key:abc"))
+			logging.FromContext(r.Context()).Info("handling request")
+			w.Write([]byte("hello"))
+		})
+		r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		r.Get("/missing", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		r.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(20 * time.Millisecond)
+		})
+	})
+	return r
+}
+
+func get(router http.Handler, path string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "10.0.0.1:40000"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// ============================================================================
+// Middleware Tests
+// ============================================================================
+
+func TestMiddleware_Record(t *testing.T) {
+	// Arrange
+	logs := captureLogs(t)
+	router := newRouter(logging.Options{SampleRate: 1, SlowThreshold: time.Second})
+
+	// Act
+	get(router, "/api/sales/ok")
+
+	// Assert: one access record with the request's details
+	access := records(t, logs, "request completed")
+	if len(access) != 1 {
+		t.Fatalf("expected 1 access record, got %d: %s", len(access), logs)
+	}
+	record := access[0]
+	want := map[string]interface{}{
+		"level":       "INFO",
+		"method":      "GET",
+		"route":       "/api/{database_id}/ok",
+		"database_id": "sales",
+		"status":      float64(200),
+		"bytes":       float64(5),
+		"client_ip":   "10.0.0.1",
+		"user":        "api_key:abc",
+		"slow":        false,
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, record[key])
+		}
+	}
+	if _, ok := record["latency_ms"].(float64); !ok {
+		t.Errorf("expected a numeric latency_ms, got %v", record["latency_ms"])
+	}
+
+	// The handler's own record carries the same request id
+	handler := records(t, logs, "handling request")
+	if len(handler) != 1 || handler[0]["request_id"] == "" || handler[0]["request_id"] != record["request_id"] {
+		t.Errorf("expected the handler log to carry the request id %v, got %v", record["request_id"], handler)
+	}
+}
+
+func TestMiddleware_Sampling(t *testing.T) {
+	// A tiny sample rate drops successful requests, but never failures or
+	// slow requests
+	logs := captureLogs(t)
+	router := newRouter(logging.Options{SampleRate: 1e-9, SlowThreshold: 10 * time.Millisecond})
+
+	for i := 0; i < 20; i++ {
+		get(router, "/api/sales/ok")
+	}
+	get(router, "/api/sales/fail")
+	get(router, "/api/sales/missing")
+	get(router, "/api/sales/slow")
+
+	levels := make(map[string]string)
+	for _, record := range records(t, logs, "request completed") {
+		levels[record["route"].(string)] = record["level"].(string)
+		if record["route"] == "/api/{database_id}/slow" && record["slow"] != true {
+			t.Error("expected the slow request to be flagged")
+		}
+	}
+
+	want := map[string]string{
+		"/api/{database_id}/fail":    "ERROR",
+		"/api/{database_id}/missing": "WARN",
+		"/api/{database_id}/slow":    "WARN",
+	}
+	if len(levels) != len(want) {
+		t.Errorf("expected only failed and slow requests to be logged, got %v", levels)
+	}
+	for route, level := range want {
+		if levels[route] != level {
+			t.Errorf("%s: expected level %s, got %q", route, level, levels[route])
+		}
+	}
+}
+
+func TestFromContext_OutsideRequest(t *testing.T) {
+	if logging.FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()) != slog.Default() {
+		t.Error("expected the default logger outside a request")
+	}
+}
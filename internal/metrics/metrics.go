@@ -0,0 +1,162 @@
+// Package metrics exposes the service's Prometheus metrics: HTTP traffic by
+// route, connection pool and admission statistics, reconnects, and domain
+// counters.
+//
+// Metrics are registered on a private registry rather than the global one,
+// so that several instances (one per test, say) do not collide.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/hotbrandon/go-chi/internal/admission"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Route label for requests that matched no route, so that scanners probing
+// random paths cannot create unbounded series.
+const unmatchedRoute = "unmatched"
+
+// Exchanges labelled by name. The exchange of a transaction is whatever the
+// client sent, so any other value is labelled "other" to bound the series.
+var knownExchanges = map[string]bool{
+	"binance":  true,
+	"bn":       true, // Binance
+	"bitfinex": true,
+	"bitstamp": true,
+	"bybit":    true,
+	"coinbase": true,
+	"gemini":   true,
+	"kraken":   true,
+	"kucoin":   true,
+	"ok":       true, // OKX
+	"okx":      true,
+}
+
+// Metrics holds the collectors of one application.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests          *prometheus.CounterVec
+	requestDuration   *prometheus.HistogramVec
+	reconnectAttempts *prometheus.CounterVec
+	reconnectFailures *prometheus.CounterVec
+	transactions      *prometheus.CounterVec
+}
+
+// New registers the metrics. pools and controllers are called on every
+// scrape and return the open connection pools and the admission statistics
+// by database id.
+func New(pools func() map[string]*sql.DB, controllers func() map[string]admission.Stats) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by route pattern, method, status and database.",
+		}, []string{"route", "method", "status", "database_id"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route pattern, method, status and database.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status", "database_id"}),
+		reconnectAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_reconnect_attempts_total",
+			Help: "Lazy reconnection attempts to a database.",
+		}, []string{"database_id"}),
+		reconnectFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_reconnect_failures_total",
+			Help: "Lazy reconnection attempts to a database that failed.",
+		}, []string{"database_id"}),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "crypto_transactions_created_total",
+			Help: "Crypto transactions created, by database and exchange.",
+		}, []string{"database_id", "exchange"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.reconnectAttempts,
+		m.reconnectFailures,
+		m.transactions,
+		newPoolCollector(pools),
+		newAdmissionCollector(controllers),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware records the count and latency of every request. It labels
+// requests with the chi route pattern rather than the path, and with the
+// {database_id} only when knownDatabase reports it as configured, so that
+// only real ids become label values.
+func (m *Metrics) Middleware(knownDatabase func(string) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			// The route is only known once the routers have matched it
+			route, dbID := unmatchedRoute, ""
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					route = pattern
+				}
+				if id := strings.ToLower(rctx.URLParam("database_id")); knownDatabase(id) {
+					dbID = id
+				}
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			labels := prometheus.Labels{
+				"route":       route,
+				"method":      r.Method,
+				"status":      strconv.Itoa(status),
+				"database_id": dbID,
+			}
+			m.requests.With(labels).Inc()
+			m.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// ReconnectAttempt counts a lazy attempt to (re)connect to dbID.
+func (m *Metrics) ReconnectAttempt(dbID string) {
+	m.reconnectAttempts.WithLabelValues(dbID).Inc()
+}
+
+// ReconnectFailure counts a lazy (re)connection to dbID that failed.
+func (m *Metrics) ReconnectFailure(dbID string) {
+	m.reconnectFailures.WithLabelValues(dbID).Inc()
+}
+
+// TransactionsCreated counts n transactions created on exchange in dbID.
+func (m *Metrics) TransactionsCreated(dbID, exchange string, n int) {
+	exchange = strings.ToLower(strings.TrimSpace(exchange))
+	switch {
+	case exchange == "":
+		exchange = "unknown"
+	case !knownExchanges[exchange]:
+		exchange = "other"
+	}
+	m.transactions.WithLabelValues(dbID, exchange).Add(float64(n))
+}
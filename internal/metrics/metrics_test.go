@@ -0,0 +1,158 @@
+package metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/admission"
+	"github.com/hotbrandon/go-chi/internal/metrics"
+)
+
+// scrape returns the text exposition of m
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected scrape status 200, got %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func assertContains(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected metrics to contain %q", line)
+		}
+	}
+}
+
+func noPools() map[string]*sql.DB { return nil }
+
+func noControllers() map[string]admission.Stats { return nil }
+
+// ============================================================================
+// HTTP Middleware Tests
+// ============================================================================
+
+func TestMiddleware_LabelsByRoutePattern(t *testing.T) {
+	// Arrange: a nested router like the application's
+	m := metrics.New(noPools, noControllers)
+	r := chi.NewRouter()
+	r.Use(m.Middleware(func(id string) bool { return id == "sales" }))
+	r.Route("/api/{database_id}", func(r chi.Router) {
+		r.Get("/crypto/transactions/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		r.Post("/crypto/transactions", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	})
+
+	// Act
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/SALES/crypto/transactions/1", nil),
+		httptest.NewRequest(http.MethodGet, "/api/sales/crypto/transactions/2", nil),
+		httptest.NewRequest(http.MethodPost, "/api/sales/crypto/transactions", nil),
+		httptest.NewRequest(http.MethodGet, "/api/bogus/crypto/transactions/1", nil),
+		httptest.NewRequest(http.MethodGet, "/wp-login.php", nil),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Assert
+	body := scrape(t, m)
+	assertContains(t, body,
+		`http_requests_total{database_id="sales",method="GET",route="/api/{database_id}/crypto/transactions/{id}",status="200"} 2`,
+		`http_requests_total{database_id="sales",method="POST",route="/api/{database_id}/crypto/transactions",status="201"} 1`,
+		`http_requests_total{database_id="",method="GET",route="/api/{database_id}/crypto/transactions/{id}",status="200"} 1`,
+		`http_requests_total{database_id="",method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{database_id="sales",method="GET",route="/api/{database_id}/crypto/transactions/{id}",status="200"} 2`,
+	)
+	if strings.Contains(body, "bogus") || strings.Contains(body, "wp-login") {
+		t.Error("expected unknown database ids and paths to stay out of labels")
+	}
+}
+
+// ============================================================================
+// Counter and Pool Tests
+// ============================================================================
+
+func TestCounters(t *testing.T) {
+	m := metrics.New(noPools, noControllers)
+
+	m.ReconnectAttempt("sales")
+	m.ReconnectAttempt("sales")
+	m.ReconnectFailure("sales")
+	m.TransactionsCreated("sales", " Coinbase ", 1)
+	m.TransactionsCreated("sales", "coinbase", 2)
+	m.TransactionsCreated("sales", "", 1)
+	m.TransactionsCreated("sales", "made-up-1", 1)
+	m.TransactionsCreated("sales", "made-up-2", 1)
+
+	assertContains(t, scrape(t, m),
+		`db_reconnect_attempts_total{database_id="sales"} 2`,
+		`db_reconnect_failures_total{database_id="sales"} 1`,
+		`crypto_transactions_created_total{database_id="sales",exchange="coinbase"} 3`,
+		`crypto_transactions_created_total{database_id="sales",exchange="unknown"} 1`,
+		`crypto_transactions_created_total{database_id="sales",exchange="other"} 2`,
+	)
+	if strings.Contains(scrape(t, m), "made-up") {
+		t.Error("expected unknown exchanges not to become label values")
+	}
+}
+
+// stubConnector never connects; pool statistics need no connection
+type stubConnector struct{}
+
+func (stubConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("not connected")
+}
+func (stubConnector) Driver() driver.Driver { return nil }
+
+func TestPoolStats(t *testing.T) {
+	db := sql.OpenDB(stubConnector{})
+	defer db.Close()
+	db.SetMaxOpenConns(7)
+
+	pools := map[string]*sql.DB{"sales": db}
+	m := metrics.New(func() map[string]*sql.DB { return pools }, noControllers)
+
+	assertContains(t, scrape(t, m),
+		`db_pool_max_open_connections{database_id="sales"} 7`,
+		`db_pool_open_connections{database_id="sales"} 0`,
+		`db_pool_wait_count_total{database_id="sales"} 0`,
+	)
+
+	// Closed pools disappear on the next scrape
+	delete(pools, "sales")
+	if strings.Contains(scrape(t, m), `database_id="sales"`) {
+		t.Error("expected no series for a removed pool")
+	}
+}
+
+func TestAdmissionStats(t *testing.T) {
+	controllers := map[string]admission.Stats{"sales": {
+		Limits:   admission.Limits{MaxInFlight: 4},
+		InFlight: 3, Queued: 2, Admitted: 10, Rejected: 1, TimedOut: 5,
+		Waited: 1500 * time.Millisecond,
+	}}
+	m := metrics.New(noPools, func() map[string]admission.Stats { return controllers })
+
+	assertContains(t, scrape(t, m),
+		`db_admission_max_in_flight{database_id="sales"} 4`,
+		`db_admission_in_flight{database_id="sales"} 3`,
+		`db_admission_queue_depth{database_id="sales"} 2`,
+		`db_admission_admitted_total{database_id="sales"} 10`,
+		`db_admission_rejections_total{database_id="sales",reason="queue_full"} 1`,
+		`db_admission_rejections_total{database_id="sales",reason="wait_timeout"} 5`,
+		`db_admission_queue_wait_seconds_total{database_id="sales"} 1.5`,
+	)
+}
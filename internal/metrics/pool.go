@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/hotbrandon/go-chi/internal/admission"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reports sql.DBStats of every open pool at scrape time.
+// Pools come and go with config reloads, so the series are built fresh on
+// each scrape instead of being kept in gauges.
+type poolCollector struct {
+	pools func() map[string]*sql.DB
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+func newPoolCollector(pools func() map[string]*sql.DB) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("db_pool_"+name, help, []string{"database_id"}, nil)
+	}
+	return &poolCollector{
+		pools:             pools,
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:              desc("open_connections", "Established connections, both in use and idle."),
+		inUse:             desc("in_use_connections", "Connections currently in use."),
+		idle:              desc("idle_connections", "Idle connections."),
+		waitCount:         desc("wait_count_total", "Total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "Total time blocked waiting for a new connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "Connections closed due to SetMaxIdleConns."),
+		maxIdleTimeClosed: desc("max_idle_time_closed_total", "Connections closed due to SetConnMaxIdleTime."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxIdleTimeClosed
+	ch <- c.maxLifetimeClosed
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for dbID, db := range c.pools() {
+		s := db.Stats()
+		gauge := func(desc *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, dbID)
+		}
+		counter := func(desc *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, dbID)
+		}
+		gauge(c.maxOpen, float64(s.MaxOpenConnections))
+		gauge(c.open, float64(s.OpenConnections))
+		gauge(c.inUse, float64(s.InUse))
+		gauge(c.idle, float64(s.Idle))
+		counter(c.waitCount, float64(s.WaitCount))
+		counter(c.waitDuration, s.WaitDuration.Seconds())
+		counter(c.maxIdleClosed, float64(s.MaxIdleClosed))
+		counter(c.maxIdleTimeClosed, float64(s.MaxIdleTimeClosed))
+		counter(c.maxLifetimeClosed, float64(s.MaxLifetimeClosed))
+	}
+}
+
+// admissionCollector reports admission.Stats of every database at scrape
+// time, for the same reason as poolCollector.
+type admissionCollector struct {
+	controllers func() map[string]admission.Stats
+
+	maxInFlight *prometheus.Desc
+	inFlight    *prometheus.Desc
+	queued      *prometheus.Desc
+	admitted    *prometheus.Desc
+	rejections  *prometheus.Desc
+	queueWait   *prometheus.Desc
+}
+
+func newAdmissionCollector(controllers func() map[string]admission.Stats) *admissionCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc("db_admission_"+name, help, append([]string{"database_id"}, labels...), nil)
+	}
+	return &admissionCollector{
+		controllers: controllers,
+		maxInFlight: desc("max_in_flight", "Maximum number of requests admitted at once."),
+		inFlight:    desc("in_flight", "Requests currently admitted."),
+		queued:      desc("queue_depth", "Requests waiting for admission."),
+		admitted:    desc("admitted_total", "Requests admitted."),
+		rejections:  desc("rejections_total", "Requests rejected, because the queue was full or the wait ran out.", "reason"),
+		queueWait:   desc("queue_wait_seconds_total", "Total time requests spent waiting for admission."),
+	}
+}
+
+func (c *admissionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxInFlight
+	ch <- c.inFlight
+	ch <- c.queued
+	ch <- c.admitted
+	ch <- c.rejections
+	ch <- c.queueWait
+}
+
+func (c *admissionCollector) Collect(ch chan<- prometheus.Metric) {
+	for dbID, s := range c.controllers() {
+		ch <- prometheus.MustNewConstMetric(c.maxInFlight, prometheus.GaugeValue, float64(s.MaxInFlight), dbID)
+		ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(s.InFlight), dbID)
+		ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(s.Queued), dbID)
+		ch <- prometheus.MustNewConstMetric(c.admitted, prometheus.CounterValue, float64(s.Admitted), dbID)
+		ch <- prometheus.MustNewConstMetric(c.rejections, prometheus.CounterValue, float64(s.Rejected), dbID, "queue_full")
+		ch <- prometheus.MustNewConstMetric(c.rejections, prometheus.CounterValue, float64(s.TimedOut), dbID, "wait_timeout")
+		ch <- prometheus.MustNewConstMetric(c.queueWait, prometheus.CounterValue, s.Waited.Seconds(), dbID)
+	}
+}
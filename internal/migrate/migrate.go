@@ -0,0 +1,445 @@
+// Package migrate applies versioned SQL migrations to a schema, recording
+// them in SCHEMA_MIGRATIONS. A lease in SCHEMA_MIGRATIONS_LOCK keeps two
+// processes from migrating the same schema at once.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+var (
+	// ErrLocked is returned when another process holds the migration lock
+	// for longer than the lock timeout.
+	ErrLocked = errors.New("schema is locked by another migration")
+	// ErrChecksum is returned when an applied migration has been edited
+	// since it ran.
+	ErrChecksum = errors.New("applied migration has been modified")
+	// ErrUnknownVersion is returned when the schema has a migration this
+	// build does not know, i.e. it was migrated by a newer release.
+	ErrUnknownVersion = errors.New("applied migration is not known to this build")
+)
+
+const (
+	migrationsTable = "SCHEMA_MIGRATIONS"
+	lockTable       = "SCHEMA_MIGRATIONS_LOCK"
+
+	// How often a blocked migration checks whether the lock was released
+	lockPollInterval = time.Second
+)
+
+// schema holds the dialect-specific statements managing the migration
+// tables. Tables are created without IF NOT EXISTS, which Oracle only
+// supports from 23ai.
+type schema struct {
+	createMigrations string
+	createLock       string
+	// Counts the tables named :1, in upper case
+	tableExists string
+}
+
+var schemas = map[string]schema{
+	"oracle": {
+		createMigrations: `CREATE TABLE SCHEMA_MIGRATIONS (
+			VERSION     NUMBER(10)          NOT NULL,
+			NAME        VARCHAR2(200 BYTE)  NOT NULL,
+			CHECKSUM    VARCHAR2(64 BYTE)   NOT NULL,
+			APPLIED_AT  DATE                DEFAULT SYSDATE NOT NULL,
+			CONSTRAINT SCHEMA_MIGRATIONS_PK PRIMARY KEY (VERSION))`,
+		createLock: `CREATE TABLE SCHEMA_MIGRATIONS_LOCK (
+			ID         NUMBER(1)           NOT NULL,
+			LOCKED_BY  VARCHAR2(200 BYTE),
+			LOCKED_AT  DATE,
+			CONSTRAINT SCHEMA_MIGRATIONS_LOCK_PK PRIMARY KEY (ID))`,
+		tableExists: `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = :1`,
+	},
+	"postgres": {
+		createMigrations: `CREATE TABLE schema_migrations (
+			version     INTEGER       NOT NULL PRIMARY KEY,
+			name        VARCHAR(200)  NOT NULL,
+			checksum    VARCHAR(64)   NOT NULL,
+			applied_at  TIMESTAMP(0)  DEFAULT LOCALTIMESTAMP(0) NOT NULL)`,
+		createLock: `CREATE TABLE schema_migrations_lock (
+			id         INTEGER       NOT NULL PRIMARY KEY,
+			locked_by  VARCHAR(200),
+			locked_at  TIMESTAMP(0))`,
+		tableExists: `SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = current_schema() AND UPPER(table_name) = :1`,
+	},
+	"sqlite": {
+		createMigrations: `CREATE TABLE SCHEMA_MIGRATIONS (
+			VERSION     INTEGER    NOT NULL PRIMARY KEY,
+			NAME        TEXT       NOT NULL,
+			CHECKSUM    TEXT       NOT NULL,
+			APPLIED_AT  TIMESTAMP  DEFAULT CURRENT_TIMESTAMP NOT NULL)`,
+		createLock: `CREATE TABLE SCHEMA_MIGRATIONS_LOCK (
+			ID         INTEGER    NOT NULL PRIMARY KEY,
+			LOCKED_BY  TEXT,
+			LOCKED_AT  TIMESTAMP)`,
+		tableExists: `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND UPPER(name) = :1`,
+	},
+}
+
+// Options configures a Migrator.
+type Options struct {
+	// SQL dialect of the database; default repo.Oracle
+	Dialect repo.Dialect
+	// Identifies the schema in logs
+	DatabaseID string
+	// Recorded as the lock holder, e.g. host and pid
+	Owner string
+	// How long to wait for another migration to finish; 0 fails at once
+	LockTimeout time.Duration
+}
+
+// Migrator applies migrations to one database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	opts       Options
+	schema     schema
+}
+
+// New returns a Migrator applying migrations, as returned by Load or
+// Embedded, to db.
+func New(db *sql.DB, migrations []Migration, opts Options) (*Migrator, error) {
+	if opts.Dialect == nil {
+		opts.Dialect = repo.Oracle
+	}
+	schema, ok := schemas[opts.Dialect.Name()]
+	if !ok {
+		return nil, fmt.Errorf("migrations are not supported on %s", opts.Dialect.Name())
+	}
+	return &Migrator{db: db, migrations: migrations, opts: opts, schema: schema}, nil
+}
+
+// exec runs a statement written with :1-style placeholders
+func (m *Migrator) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, m.opts.Dialect.Rebind(query), args...)
+}
+
+func (m *Migrator) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, m.opts.Dialect.Rebind(query), args...)
+}
+
+// Status describes one migration, known or applied.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Why the migration needs attention: ErrChecksum or ErrUnknownVersion
+	Problem error
+}
+
+// applied is a row of SCHEMA_MIGRATIONS
+type applied struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Status reports every migration of this build and every applied one,
+// sorted by version. It does not create the migration tables.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	rows, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]Status)
+	for _, mig := range m.migrations {
+		byVersion[mig.Version] = Status{Version: mig.Version, Name: mig.Name}
+	}
+	for _, row := range rows {
+		status := Status{Version: row.version, Name: row.name, Applied: true, AppliedAt: row.appliedAt}
+		status.Problem = m.check(row)
+		byVersion[row.version] = status
+	}
+
+	statuses := make([]Status, 0, len(byVersion))
+	for _, status := range byVersion {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Up applies every pending migration up to and including target (0 for
+// all), in version order, and returns those it applied. It refuses to run
+// if an applied migration was modified or is unknown.
+//
+// Oracle commits DDL implicitly, so a migration that fails part way leaves
+// its earlier statements applied; the error says which statement failed.
+func (m *Migrator) Up(ctx context.Context, target int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(rows []applied) error {
+		isApplied := make(map[int]bool, len(rows))
+		for _, row := range rows {
+			isApplied[row.version] = true
+		}
+
+		for _, mig := range m.migrations {
+			if isApplied[mig.Version] || (target > 0 && mig.Version > target) {
+				continue
+			}
+			slog.Info("applying migration",
+				"database_id", m.opts.DatabaseID,
+				"version", mig.Version,
+				"name", mig.Name)
+			if err := m.run(ctx, mig, mig.Up); err != nil {
+				return err
+			}
+			if _, err := m.exec(ctx,
+				`INSERT INTO SCHEMA_MIGRATIONS (VERSION, NAME, CHECKSUM) VALUES (:1, :2, :3)`,
+				mig.Version, mig.Name, mig.Checksum); err != nil {
+				return fmt.Errorf("migration %d %s was applied but not recorded: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// those it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(rows []applied) error {
+		for i := len(rows) - 1; i >= 0 && len(done) < steps; i-- {
+			mig, _ := m.migration(rows[i].version)
+			if len(mig.Down) == 0 {
+				return fmt.Errorf("migration %d %s has no down statements", mig.Version, mig.Name)
+			}
+			slog.Info("reverting migration",
+				"database_id", m.opts.DatabaseID,
+				"version", mig.Version,
+				"name", mig.Name)
+			if err := m.run(ctx, mig, mig.Down); err != nil {
+				return err
+			}
+			if _, err := m.exec(ctx,
+				`DELETE FROM SCHEMA_MIGRATIONS WHERE VERSION = :1`, mig.Version); err != nil {
+				return fmt.Errorf("migration %d %s was reverted but is still recorded: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline records every migration up to and including version as applied
+// without running it, for schemas created by hand before migrations were
+// introduced.
+func (m *Migrator) Baseline(ctx context.Context, version int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(rows []applied) error {
+		isApplied := make(map[int]bool, len(rows))
+		for _, row := range rows {
+			isApplied[row.version] = true
+		}
+		for _, mig := range m.migrations {
+			if isApplied[mig.Version] || mig.Version > version {
+				continue
+			}
+			if _, err := m.exec(ctx,
+				`INSERT INTO SCHEMA_MIGRATIONS (VERSION, NAME, CHECKSUM) VALUES (:1, :2, :3)`,
+				mig.Version, mig.Name, mig.Checksum); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Unlock releases the migration lock whoever holds it. Use it only when the
+// holder is known to be gone, e.g. killed mid-migration.
+func (m *Migrator) Unlock(ctx context.Context) error {
+	_, err := m.exec(ctx,
+		`UPDATE SCHEMA_MIGRATIONS_LOCK SET LOCKED_BY = NULL, LOCKED_AT = NULL WHERE ID = 1`)
+	return err
+}
+
+// locked creates the migration tables if needed, takes the lock, validates
+// the applied migrations and runs f with them, sorted by version.
+func (m *Migrator) locked(ctx context.Context, f func([]applied) error) (err error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		// Release even if ctx was cancelled mid-migration
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if _, releaseErr := m.exec(releaseCtx,
+			`UPDATE SCHEMA_MIGRATIONS_LOCK SET LOCKED_BY = NULL, LOCKED_AT = NULL WHERE ID = 1 AND LOCKED_BY = :1`,
+			m.opts.Owner); releaseErr != nil && err == nil {
+			err = fmt.Errorf("release migration lock: %w", releaseErr)
+		}
+	}()
+
+	rows, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := m.check(row); err != nil {
+			return fmt.Errorf("migration %d %s: %w", row.version, row.name, err)
+		}
+	}
+	return f(rows)
+}
+
+// lock takes the lease in SCHEMA_MIGRATIONS_LOCK, waiting up to the lock
+// timeout. A lease row, rather than a row lock or DBMS_LOCK, survives the
+// commits implied by DDL and needs no extra grants.
+func (m *Migrator) lock(ctx context.Context) error {
+	deadline := time.Now().Add(m.opts.LockTimeout)
+	for {
+		result, err := m.exec(ctx,
+			`UPDATE SCHEMA_MIGRATIONS_LOCK SET LOCKED_BY = :1, LOCKED_AT = CURRENT_TIMESTAMP WHERE ID = 1 AND LOCKED_BY IS NULL`,
+			m.opts.Owner)
+		if err != nil {
+			return fmt.Errorf("take migration lock: %w", err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 1 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			var holder string
+			var since time.Time
+			err := m.queryRow(ctx,
+				`SELECT LOCKED_BY, LOCKED_AT FROM SCHEMA_MIGRATIONS_LOCK WHERE ID = 1`).Scan(&holder, &since)
+			if err != nil {
+				return ErrLocked
+			}
+			return fmt.Errorf("%w: held by %s since %s; run `migrate unlock` if it is gone",
+				ErrLocked, holder, since.Format(time.RFC3339))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// ensureTables creates the migration and lock tables and the lock row. Two
+// processes may race to create them; whoever loses finds them created and
+// ignores its error.
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	for _, t := range []struct{ table, create string }{
+		{migrationsTable, m.schema.createMigrations},
+		{lockTable, m.schema.createLock},
+	} {
+		table, create := t.table, t.create
+		exists, err := m.tableExists(ctx, table)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := m.exec(ctx, create); err != nil {
+			if exists, _ := m.tableExists(ctx, table); !exists {
+				return fmt.Errorf("create %s: %w", table, err)
+			}
+		}
+	}
+
+	if exists, err := m.lockRowExists(ctx); err != nil || exists {
+		return err
+	}
+	if _, err := m.exec(ctx, `INSERT INTO SCHEMA_MIGRATIONS_LOCK (ID) VALUES (1)`); err != nil {
+		if exists, _ := m.lockRowExists(ctx); !exists {
+			return fmt.Errorf("create migration lock: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) tableExists(ctx context.Context, table string) (bool, error) {
+	var count int
+	err := m.queryRow(ctx, m.schema.tableExists, table).Scan(&count)
+	return count > 0, err
+}
+
+func (m *Migrator) lockRowExists(ctx context.Context) (bool, error) {
+	var count int
+	err := m.queryRow(ctx, `SELECT COUNT(*) FROM SCHEMA_MIGRATIONS_LOCK WHERE ID = 1`).Scan(&count)
+	return count > 0, err
+}
+
+// applied returns the rows of SCHEMA_MIGRATIONS sorted by version, or none
+// when the table does not exist yet.
+func (m *Migrator) applied(ctx context.Context) ([]applied, error) {
+	exists, err := m.tableExists(ctx, migrationsTable)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT VERSION, NAME, CHECKSUM, APPLIED_AT FROM SCHEMA_MIGRATIONS ORDER BY VERSION`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []applied
+	for rows.Next() {
+		var row applied
+		if err := rows.Scan(&row.version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// check compares an applied migration with this build's copy
+func (m *Migrator) check(row applied) error {
+	mig, known := m.migration(row.version)
+	if !known {
+		return ErrUnknownVersion
+	}
+	if mig.Checksum != row.checksum {
+		return ErrChecksum
+	}
+	return nil
+}
+
+func (m *Migrator) migration(version int) (Migration, bool) {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+// run executes statements one at a time, as go-ora does not accept scripts
+func (m *Migrator) run(ctx context.Context, mig Migration, statements []string) error {
+	for i, statement := range statements {
+		if _, err := m.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d %s: statement %d of %d failed (earlier statements were committed): %w",
+				mig.Version, mig.Name, i+1, len(statements), err)
+		}
+	}
+	return nil
+}
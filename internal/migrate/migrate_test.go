@@ -0,0 +1,382 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/migrate"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// fakeSchema emulates the migration tables of one Oracle schema and records
+// every other statement executed against it
+type fakeSchema struct {
+	mu       sync.Mutex
+	tables   map[string]bool
+	applied  map[int][]driver.Value // version -> VERSION, NAME, CHECKSUM, APPLIED_AT
+	lockedBy string
+	executed []string
+	failOn   string
+}
+
+func newFakeSchema() *fakeSchema {
+	return &fakeSchema{tables: make(map[string]bool), applied: make(map[int][]driver.Value)}
+}
+
+func (s *fakeSchema) Connect(context.Context) (driver.Conn, error) { return &fakeConn{s}, nil }
+func (s *fakeSchema) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ s *fakeSchema }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "FROM USER_TABLES"):
+		count := int64(0)
+		if s.tables[args[0].Value.(string)] {
+			count = 1
+		}
+		return &fakeRows{columns: []string{"COUNT"}, rows: [][]driver.Value{{count}}}, nil
+	case strings.Contains(query, "FROM SCHEMA_MIGRATIONS ORDER BY"):
+		versions := make([]int, 0, len(s.applied))
+		for v := range s.applied {
+			versions = append(versions, v)
+		}
+		sort.Ints(versions)
+		rows := &fakeRows{columns: []string{"VERSION", "NAME", "CHECKSUM", "APPLIED_AT"}}
+		for _, v := range versions {
+			rows.rows = append(rows.rows, s.applied[v])
+		}
+		return rows, nil
+	case strings.Contains(query, "COUNT(*) FROM SCHEMA_MIGRATIONS_LOCK"):
+		return &fakeRows{columns: []string{"COUNT"}, rows: [][]driver.Value{{int64(1)}}}, nil
+	case strings.Contains(query, "FROM SCHEMA_MIGRATIONS_LOCK"):
+		return &fakeRows{columns: []string{"LOCKED_BY", "LOCKED_AT"}, rows: [][]driver.Value{{s.lockedBy, time.Now()}}}, nil
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE SCHEMA_MIGRATIONS_LOCK"):
+		s.tables["SCHEMA_MIGRATIONS_LOCK"] = true
+	case strings.HasPrefix(query, "CREATE TABLE SCHEMA_MIGRATIONS"):
+		s.tables["SCHEMA_MIGRATIONS"] = true
+	case strings.HasPrefix(query, "INSERT INTO SCHEMA_MIGRATIONS_LOCK"):
+	case strings.HasPrefix(query, "UPDATE SCHEMA_MIGRATIONS_LOCK SET LOCKED_BY = :1"):
+		if s.lockedBy != "" {
+			return driver.RowsAffected(0), nil
+		}
+		s.lockedBy = args[0].Value.(string)
+	case strings.HasPrefix(query, "UPDATE SCHEMA_MIGRATIONS_LOCK SET LOCKED_BY = NULL"):
+		if len(args) == 0 || args[0].Value == s.lockedBy {
+			s.lockedBy = ""
+		}
+	case strings.HasPrefix(query, "INSERT INTO SCHEMA_MIGRATIONS"):
+		version := args[0].Value.(int64)
+		s.applied[int(version)] = []driver.Value{version, args[1].Value, args[2].Value, time.Now()}
+	case strings.HasPrefix(query, "DELETE FROM SCHEMA_MIGRATIONS"):
+		delete(s.applied, int(args[0].Value.(int64)))
+	default:
+		if s.failOn != "" && strings.Contains(query, s.failOn) {
+			return nil, errors.New("ORA-00942: table or view does not exist")
+		}
+		s.executed = append(s.executed, query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var testMigrations = fstest.MapFS{
+	"0001_create_coins.up.sql": {Data: []byte(`-- coins
+CREATE TABLE COINS (SYMBOL VARCHAR2(10));
+
+CREATE INDEX COINS_IDX
+ON COINS (SYMBOL);
+`)},
+	"0001_create_coins.down.sql": {Data: []byte("DROP TABLE COINS;\n")},
+	"0002_seed_coins.up.sql": {Data: []byte(`BEGIN
+  INSERT INTO COINS VALUES ('BTC');
+  INSERT INTO COINS VALUES ('ETH');
+END;
+/
+`)},
+	"0002_seed_coins.down.sql": {Data: []byte("DELETE FROM COINS;\n")},
+}
+
+func newMigrator(t *testing.T, schema *fakeSchema, fsys fstest.MapFS) *migrate.Migrator {
+	t.Helper()
+	migrations, err := migrate.Load(fsys)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	db := sql.OpenDB(schema)
+	t.Cleanup(func() { db.Close() })
+	m, err := migrate.New(db, migrations, migrate.Options{DatabaseID: "sales", Owner: "test"})
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+	return m
+}
+
+// ============================================================================
+// Load Tests
+// ============================================================================
+
+func TestLoad(t *testing.T) {
+	// Act
+	migrations, err := migrate.Load(testMigrations)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Name != "seed_coins" {
+		t.Fatalf("unexpected migrations %+v", migrations)
+	}
+
+	wantUp := []string{"CREATE TABLE COINS (SYMBOL VARCHAR2(10))", "CREATE INDEX COINS_IDX\nON COINS (SYMBOL)"}
+	if strings.Join(migrations[0].Up, "|") != strings.Join(wantUp, "|") {
+		t.Errorf("expected statements %q, got %q", wantUp, migrations[0].Up)
+	}
+	if len(migrations[1].Up) != 1 || !strings.HasSuffix(migrations[1].Up[0], "END;") {
+		t.Errorf("expected one PL/SQL block keeping its END;, got %q", migrations[1].Up)
+	}
+	if len(migrations[0].Checksum) != 64 || migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("expected distinct SHA-256 checksums, got %q and %q", migrations[0].Checksum, migrations[1].Checksum)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"misnamed file", fstest.MapFS{"create_coins.sql": {Data: []byte("SELECT 1 FROM DUAL;")}}},
+		{"down without up", fstest.MapFS{"0001_coins.down.sql": {Data: []byte("DROP TABLE COINS;")}}},
+		{"duplicate version", fstest.MapFS{
+			"0001_coins.up.sql": {Data: []byte("CREATE TABLE COINS (ID NUMBER);")},
+			"0001_other.up.sql": {Data: []byte("CREATE TABLE OTHER (ID NUMBER);")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := migrate.Load(tt.fsys); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	counts := make(map[string]int)
+	for _, dialect := range repo.DialectNames {
+		t.Run(dialect, func(t *testing.T) {
+			migrations, err := migrate.Embedded(dialect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(migrations) == 0 || migrations[0].Name != "create_transactions" || len(migrations[0].Down) == 0 {
+				t.Fatalf("expected the transactions table as the first reversible migration, got %+v", migrations)
+			}
+			counts[dialect] = len(migrations)
+		})
+	}
+	// Every dialect must define the same versions
+	for _, n := range counts {
+		if n != counts["oracle"] {
+			t.Errorf("expected every dialect to have the same number of migrations, got %v", counts)
+			break
+		}
+	}
+}
+
+// ============================================================================
+// Migrator Tests
+// ============================================================================
+
+func TestMigrator_UpAndDown(t *testing.T) {
+	// Arrange
+	schema := newFakeSchema()
+	m := newMigrator(t, schema, testMigrations)
+	ctx := context.Background()
+
+	// Act
+	applied, err := m.Up(ctx, 0)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != 2 || len(schema.executed) != 3 || len(schema.applied) != 2 {
+		t.Fatalf("expected both migrations applied, got %+v (executed %q)", applied, schema.executed)
+	}
+	if schema.lockedBy != "" {
+		t.Errorf("expected the lock to be released, held by %q", schema.lockedBy)
+	}
+
+	// Act: nothing is pending the second time
+	applied, err = m.Up(ctx, 0)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("expected no migrations, got %+v, %v", applied, err)
+	}
+
+	// Act: revert the newest migration
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != 2 || schema.executed[len(schema.executed)-1] != "DELETE FROM COINS" {
+		t.Errorf("expected migration 2 reverted, got %+v", reverted)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("expected 1 applied and 2 pending, got %+v", statuses)
+	}
+}
+
+func TestMigrator_UpToTarget(t *testing.T) {
+	schema := newFakeSchema()
+	m := newMigrator(t, schema, testMigrations)
+
+	applied, err := m.Up(context.Background(), 1)
+	if err != nil || len(applied) != 1 || applied[0].Version != 1 {
+		t.Errorf("expected only migration 1, got %+v, %v", applied, err)
+	}
+}
+
+func TestMigrator_Baseline(t *testing.T) {
+	// Arrange
+	schema := newFakeSchema()
+	m := newMigrator(t, schema, testMigrations)
+
+	// Act
+	recorded, err := m.Baseline(context.Background(), 1)
+
+	// Assert: recorded without running
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("expected migration 1 recorded, got %+v, %v", recorded, err)
+	}
+	if len(schema.executed) != 0 {
+		t.Errorf("expected no statements executed, got %q", schema.executed)
+	}
+	applied, err := m.Up(context.Background(), 0)
+	if err != nil || len(applied) != 1 || applied[0].Version != 2 {
+		t.Errorf("expected only migration 2 applied after the baseline, got %+v, %v", applied, err)
+	}
+}
+
+func TestMigrator_ModifiedMigration(t *testing.T) {
+	// Arrange
+	schema := newFakeSchema()
+	if _, err := newMigrator(t, schema, testMigrations).Up(context.Background(), 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	edited := fstest.MapFS{}
+	for name, file := range testMigrations {
+		edited[name] = file
+	}
+	edited["0001_create_coins.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE COINS (SYMBOL VARCHAR2(20));\n")}
+	m := newMigrator(t, schema, edited)
+
+	// Act
+	_, err := m.Up(context.Background(), 0)
+
+	// Assert
+	if !errors.Is(err, migrate.ErrChecksum) {
+		t.Errorf("expected ErrChecksum, got %v", err)
+	}
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(statuses[0].Problem, migrate.ErrChecksum) || statuses[1].Problem != nil {
+		t.Errorf("expected only migration 1 flagged, got %+v", statuses)
+	}
+}
+
+func TestMigrator_Locked(t *testing.T) {
+	// Arrange
+	schema := newFakeSchema()
+	m := newMigrator(t, schema, testMigrations)
+	schema.tables["SCHEMA_MIGRATIONS"], schema.tables["SCHEMA_MIGRATIONS_LOCK"] = true, true
+	schema.lockedBy = "other-host:42"
+
+	// Act
+	_, err := m.Up(context.Background(), 0)
+
+	// Assert
+	if !errors.Is(err, migrate.ErrLocked) || !strings.Contains(err.Error(), "other-host:42") {
+		t.Fatalf("expected ErrLocked naming the holder, got %v", err)
+	}
+	if len(schema.executed) != 0 || schema.lockedBy != "other-host:42" {
+		t.Errorf("expected nothing to run and the lock to be kept")
+	}
+
+	if err := m.Unlock(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Errorf("expected the migration to run after unlocking, got %v", err)
+	}
+}
+
+func TestMigrator_FailedStatement(t *testing.T) {
+	// Arrange
+	schema := newFakeSchema()
+	schema.failOn = "CREATE INDEX"
+	m := newMigrator(t, schema, testMigrations)
+
+	// Act
+	applied, err := m.Up(context.Background(), 0)
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "statement 2 of 2") {
+		t.Fatalf("expected the failing statement to be named, got %v", err)
+	}
+	if len(applied) != 0 || len(schema.applied) != 0 {
+		t.Errorf("expected the failed migration not to be recorded")
+	}
+	if schema.lockedBy != "" {
+		t.Errorf("expected the lock to be released after a failure")
+	}
+}
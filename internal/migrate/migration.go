@@ -0,0 +1,148 @@
+package migrate
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// One directory of migrations per dialect
+//
+//go:embed migrations
+var embedded embed.FS
+
+// Migration is one schema version: the statements that apply it and those
+// that revert it.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       []string
+	Down     []string // empty when the migration cannot be reverted
+	Checksum string   // SHA-256 of the up script
+}
+
+// migrationFile matches <version>_<name>.up.sql and .down.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Embedded returns the migrations built into the binary for a dialect
+// ("oracle", "postgres" or "sqlite").
+func Embedded(dialect string) ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "migrations/"+dialect)
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load reads the migrations in the root of fsys, sorted by version. Files
+// that do not follow the naming scheme are rejected rather than ignored, so
+// that a typo cannot silently skip a migration.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%s: expected <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("%s: invalid version", entry.Name())
+		}
+
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+
+		statements := SplitStatements(string(script))
+		if match[3] == "up" {
+			sum := sha256.Sum256(script)
+			m.Up, m.Checksum = statements, hex.EncodeToString(sum[:])
+		} else {
+			m.Down = statements
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if len(m.Up) == 0 {
+			return nil, fmt.Errorf("migration %d %s has no up statements", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// plsqlBlock matches the start of statements whose body contains semicolons
+// and which therefore end with a line holding only "/", as in SQL*Plus
+var plsqlBlock = regexp.MustCompile(`(?i)^(BEGIN|DECLARE|CREATE\s+(OR\s+REPLACE\s+)?(PROCEDURE|FUNCTION|PACKAGE|TRIGGER|TYPE))\b`)
+
+// SplitStatements splits a script into statements that can be executed one
+// at a time. SQL statements end with a semicolon at the end of a line,
+// which is dropped since Oracle rejects it; PL/SQL blocks end with a line
+// holding only "/" and keep their final "END;". Comment lines between
+// statements are skipped.
+func SplitStatements(script string) []string {
+	var (
+		statements []string
+		current    []string
+		plsql      bool
+	)
+	flush := func() {
+		statement := strings.TrimSpace(strings.Join(current, "\n"))
+		if !plsql {
+			statement = strings.TrimSpace(strings.TrimSuffix(statement, ";"))
+		}
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+		current, plsql = nil, false
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		if len(current) == 0 {
+			if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+				continue
+			}
+			plsql = plsqlBlock.MatchString(trimmed)
+		}
+		if trimmed == "/" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+		if !plsql && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	flush()
+	return statements
+}
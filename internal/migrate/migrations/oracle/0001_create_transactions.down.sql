@@ -0,0 +1,3 @@
+DROP SEQUENCE TRANSACTIONS_SEQ;
+
+DROP TABLE TRANSACTIONS CASCADE CONSTRAINTS PURGE;
@@ -0,0 +1,50 @@
+-- Cryptocurrency transactions, as documented in tables.md
+CREATE TABLE TRANSACTIONS
+(
+  TRANSACTIONS_SEQ  NUMBER,
+  COIN_SYMBOL       VARCHAR2(10 BYTE)           NOT NULL,
+  TRANSACTION_TYPE  CHAR(1 BYTE)                NOT NULL,
+  QUANTITY          NUMBER(20,8)                NOT NULL,
+  PRICE_PER_UNIT    NUMBER(20,8)                NOT NULL,
+  TOTAL_COST        NUMBER(20,2)                NOT NULL,
+  TRANSACTION_DATE  DATE                        NOT NULL,
+  EXCHANGE          CHAR(2 BYTE)                NOT NULL,
+  NOTES             VARCHAR2(50 BYTE),
+  CREATED_AT        DATE                        DEFAULT SYSDATE
+);
+
+ALTER TABLE TRANSACTIONS
+ADD CONSTRAINT TRANSACTIONS_PK
+PRIMARY KEY (TRANSACTIONS_SEQ);
+
+ALTER TABLE TRANSACTIONS
+ADD CONSTRAINT TRANSACTION_TYPE_CHK
+CHECK (TRANSACTION_TYPE IN ('B', 'S'));
+
+ALTER TABLE TRANSACTIONS
+ADD CONSTRAINT EXCHANGE_CHK
+CHECK (EXCHANGE IN ('BN', 'OK'));
+
+ALTER TABLE TRANSACTIONS
+ADD CONSTRAINT QUANTITY_POSITIVE_CHK
+CHECK (QUANTITY > 0);
+
+ALTER TABLE TRANSACTIONS
+ADD CONSTRAINT PRICE_POSITIVE_CHK
+CHECK (PRICE_PER_UNIT > 0);
+
+ALTER TABLE TRANSACTIONS
+ADD CONSTRAINT TOTAL_COST_POSITIVE_CHK
+CHECK (TOTAL_COST >= 0);
+
+CREATE INDEX TRANSACTIONS_SYMBOL_IDX
+ON TRANSACTIONS (COIN_SYMBOL);
+
+CREATE INDEX TRANSACTIONS_DATE_IDX
+ON TRANSACTIONS (TRANSACTION_DATE);
+
+CREATE SEQUENCE TRANSACTIONS_SEQ
+  START WITH 1
+  INCREMENT BY 1
+  NOCACHE
+  NOCYCLE;
@@ -0,0 +1,3 @@
+DROP SEQUENCE TRANSACTIONS_AUDIT_SEQ;
+
+DROP TABLE TRANSACTIONS_AUDIT CASCADE CONSTRAINTS PURGE;
@@ -0,0 +1,34 @@
+-- Audit trail of the changes to TRANSACTIONS, as documented in tables.md.
+-- Entries outlive the transactions they record, so there is no foreign key.
+CREATE TABLE TRANSACTIONS_AUDIT
+(
+  AUDIT_SEQ         NUMBER,
+  TRANSACTIONS_SEQ  NUMBER                      NOT NULL,
+  OPERATION         VARCHAR2(10 BYTE)           NOT NULL,
+  ACTOR             VARCHAR2(200 BYTE)          NOT NULL,
+  REQUEST_ID        VARCHAR2(100 BYTE),
+  DATABASE_ID       VARCHAR2(64 BYTE)           NOT NULL,
+  BEFORE_DATA       CLOB,
+  AFTER_DATA        CLOB,
+  CHANGED_AT        DATE                        DEFAULT SYSDATE NOT NULL
+);
+
+ALTER TABLE TRANSACTIONS_AUDIT
+ADD CONSTRAINT TRANSACTIONS_AUDIT_PK
+PRIMARY KEY (AUDIT_SEQ);
+
+ALTER TABLE TRANSACTIONS_AUDIT
+ADD CONSTRAINT AUDIT_OPERATION_CHK
+CHECK (OPERATION IN ('CREATE', 'UPDATE', 'DELETE', 'IMPORT'));
+
+CREATE INDEX TRANSACTIONS_AUDIT_SEQ_IDX
+ON TRANSACTIONS_AUDIT (TRANSACTIONS_SEQ);
+
+CREATE INDEX TRANSACTIONS_AUDIT_CHANGED_IDX
+ON TRANSACTIONS_AUDIT (CHANGED_AT);
+
+CREATE SEQUENCE TRANSACTIONS_AUDIT_SEQ
+  START WITH 1
+  INCREMENT BY 1
+  NOCACHE
+  NOCYCLE;
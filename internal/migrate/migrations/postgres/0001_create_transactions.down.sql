@@ -0,0 +1,3 @@
+DROP TABLE transactions;
+
+DROP SEQUENCE transactions_seq;
@@ -0,0 +1,20 @@
+-- Cryptocurrency transactions, as in the Oracle schema
+CREATE SEQUENCE transactions_seq START WITH 1 INCREMENT BY 1 NO CYCLE;
+
+CREATE TABLE transactions
+(
+  transactions_seq  BIGINT         PRIMARY KEY,
+  coin_symbol       VARCHAR(10)    NOT NULL,
+  transaction_type  CHAR(1)        NOT NULL CONSTRAINT transaction_type_chk CHECK (transaction_type IN ('B', 'S')),
+  quantity          NUMERIC(20,8)  NOT NULL CONSTRAINT quantity_positive_chk CHECK (quantity > 0),
+  price_per_unit    NUMERIC(20,8)  NOT NULL CONSTRAINT price_positive_chk CHECK (price_per_unit > 0),
+  total_cost        NUMERIC(20,2)  NOT NULL CONSTRAINT total_cost_positive_chk CHECK (total_cost >= 0),
+  transaction_date  DATE           NOT NULL,
+  exchange          CHAR(2)        NOT NULL CONSTRAINT exchange_chk CHECK (exchange IN ('BN', 'OK')),
+  notes             VARCHAR(50),
+  created_at        TIMESTAMP(0)   DEFAULT LOCALTIMESTAMP(0)
+);
+
+CREATE INDEX transactions_symbol_idx ON transactions (coin_symbol);
+
+CREATE INDEX transactions_date_idx ON transactions (transaction_date);
@@ -0,0 +1,3 @@
+DROP TABLE transactions_audit;
+
+DROP SEQUENCE transactions_audit_seq;
@@ -0,0 +1,19 @@
+-- Audit trail of the changes to transactions, as in the Oracle schema
+CREATE SEQUENCE transactions_audit_seq START WITH 1 INCREMENT BY 1 NO CYCLE;
+
+CREATE TABLE transactions_audit
+(
+  audit_seq         BIGINT        PRIMARY KEY,
+  transactions_seq  BIGINT        NOT NULL,
+  operation         VARCHAR(10)   NOT NULL CONSTRAINT audit_operation_chk CHECK (operation IN ('CREATE', 'UPDATE', 'DELETE', 'IMPORT')),
+  actor             VARCHAR(200)  NOT NULL,
+  request_id        VARCHAR(100),
+  database_id       VARCHAR(64)   NOT NULL,
+  before_data       JSONB,
+  after_data        JSONB,
+  changed_at        TIMESTAMP(0)  NOT NULL DEFAULT LOCALTIMESTAMP(0)
+);
+
+CREATE INDEX transactions_audit_seq_idx ON transactions_audit (transactions_seq);
+
+CREATE INDEX transactions_audit_changed_idx ON transactions_audit (changed_at);
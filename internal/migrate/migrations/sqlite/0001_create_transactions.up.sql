@@ -0,0 +1,19 @@
+-- Cryptocurrency transactions, as in the Oracle schema. Dates are stored
+-- as ISO 8601 text; inserting NULL into TRANSACTIONS_SEQ assigns the next id.
+CREATE TABLE TRANSACTIONS
+(
+  TRANSACTIONS_SEQ  INTEGER  PRIMARY KEY,
+  COIN_SYMBOL       TEXT     NOT NULL CHECK (length(COIN_SYMBOL) <= 10),
+  TRANSACTION_TYPE  TEXT     NOT NULL CONSTRAINT TRANSACTION_TYPE_CHK CHECK (TRANSACTION_TYPE IN ('B', 'S')),
+  QUANTITY          REAL     NOT NULL CONSTRAINT QUANTITY_POSITIVE_CHK CHECK (QUANTITY > 0),
+  PRICE_PER_UNIT    REAL     NOT NULL CONSTRAINT PRICE_POSITIVE_CHK CHECK (PRICE_PER_UNIT > 0),
+  TOTAL_COST        REAL     NOT NULL CONSTRAINT TOTAL_COST_POSITIVE_CHK CHECK (TOTAL_COST >= 0),
+  TRANSACTION_DATE  TEXT     NOT NULL,
+  EXCHANGE          TEXT     NOT NULL CONSTRAINT EXCHANGE_CHK CHECK (EXCHANGE IN ('BN', 'OK')),
+  NOTES             TEXT     CHECK (length(NOTES) <= 50),
+  CREATED_AT        TEXT     DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX TRANSACTIONS_SYMBOL_IDX ON TRANSACTIONS (COIN_SYMBOL);
+
+CREATE INDEX TRANSACTIONS_DATE_IDX ON TRANSACTIONS (TRANSACTION_DATE);
@@ -0,0 +1,18 @@
+-- Audit trail of the changes to TRANSACTIONS, as in the Oracle schema.
+-- Snapshots are JSON text; inserting NULL into AUDIT_SEQ assigns the next id.
+CREATE TABLE TRANSACTIONS_AUDIT
+(
+  AUDIT_SEQ         INTEGER  PRIMARY KEY,
+  TRANSACTIONS_SEQ  INTEGER  NOT NULL,
+  OPERATION         TEXT     NOT NULL CONSTRAINT AUDIT_OPERATION_CHK CHECK (OPERATION IN ('CREATE', 'UPDATE', 'DELETE', 'IMPORT')),
+  ACTOR             TEXT     NOT NULL,
+  REQUEST_ID        TEXT,
+  DATABASE_ID       TEXT     NOT NULL,
+  BEFORE_DATA       TEXT,
+  AFTER_DATA        TEXT,
+  CHANGED_AT        TEXT     NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX TRANSACTIONS_AUDIT_SEQ_IDX ON TRANSACTIONS_AUDIT (TRANSACTIONS_SEQ);
+
+CREATE INDEX TRANSACTIONS_AUDIT_CHANGED_IDX ON TRANSACTIONS_AUDIT (CHANGED_AT);
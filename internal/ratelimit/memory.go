@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// How often idle buckets are dropped from a MemoryStore.
+const pruneInterval = time.Minute
+
+// MemoryStore keeps token buckets in memory. Each replica enforces its own
+// limits, so the effective global limit is the configured one times the
+// number of replicas.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket will have refilled completely
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.pruneLocked(now)
+
+	burst := float64(limit.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+
+	// Refill for the time since the last request; a lowered burst applies
+	// immediately
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	decision := Decision{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = seconds((burst - b.tokens) / limit.Rate)
+	b.full = now.Add(decision.Reset)
+	return decision, nil
+}
+
+func (s *MemoryStore) Refund(ctx context.Context, key string, limit Limit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A pruned bucket is full already
+	if b, ok := s.buckets[key]; ok {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+1)
+		b.full = b.updated.Add(seconds((float64(limit.Burst) - b.tokens) / limit.Rate))
+	}
+	return nil
+}
+
+// pruneLocked drops buckets that have refilled, since a new bucket starts
+// full anyway.
+func (s *MemoryStore) pruneLocked(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+	for key, b := range s.buckets {
+		if now.After(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
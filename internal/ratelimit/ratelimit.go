@@ -0,0 +1,162 @@
+// Package ratelimit limits request rates with token buckets, per client and
+// per database id.
+//
+// Buckets live in a Store. MemoryStore keeps them in process, which limits
+// each replica separately; a shared Store (Redis, a database table, ...)
+// lets several replicas enforce one global limit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/apierror"
+	"github.com/hotbrandon/go-chi/internal/auth"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at Rate per
+// second. The zero Limit disables limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Decision is the outcome of taking a token from a bucket.
+type Decision struct {
+	Allowed    bool
+	Limit      int           // bucket size
+	Remaining  int           // whole tokens left
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, when not allowed
+}
+
+// Store holds token buckets by key.
+type Store interface {
+	// Take removes one token from the bucket for key, if there is one.
+	Take(ctx context.Context, key string, limit Limit) (Decision, error)
+	// Refund puts back a token taken for a request that another bucket
+	// then denied.
+	Refund(ctx context.Context, key string, limit Limit) error
+}
+
+// Rules are the limits applied to one route group.
+type Rules struct {
+	PerClient   Limit // per API key, token subject or client IP
+	PerDatabase Limit // per {database_id}, shared by all clients
+}
+
+type bucket struct {
+	key   string
+	limit Limit
+}
+
+// Middleware limits requests in a route group. The rules are read on every
+// request so that configuration reloads apply immediately. When the store
+// fails, requests are let through rather than turning an outage of the
+// limiter into an outage of the API.
+func Middleware(store Store, group string, rules func() Rules) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := rules()
+
+			var buckets []bucket
+			if current.PerClient.Enabled() {
+				buckets = append(buckets, bucket{group + ":client:" + clientKey(r), current.PerClient})
+			}
+			if dbID := strings.ToLower(chi.URLParam(r, "database_id")); dbID != "" && current.PerDatabase.Enabled() {
+				buckets = append(buckets, bucket{DatabaseKey(group, dbID), current.PerDatabase})
+			}
+
+			var tightest *Decision
+			var taken []bucket
+			for _, b := range buckets {
+				decision, err := store.Take(r.Context(), b.key, b.limit)
+				if err != nil {
+					slog.Warn("rate limiter unavailable, allowing request",
+						"key", b.key,
+						"error", err)
+					continue
+				}
+				if tightest == nil || decision.Remaining < tightest.Remaining || !decision.Allowed {
+					tightest = &decision
+				}
+				if !decision.Allowed {
+					// A denied request must not use up the client's quota
+					refund(r.Context(), store, taken)
+					break
+				}
+				taken = append(taken, b)
+			}
+
+			if tightest == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			setHeaders(w, *tightest)
+			if !tightest.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(tightest.RetryAfter)))
+				apierror.Write(w, http.StatusTooManyRequests,
+					"Too Many Requests",
+					fmt.Sprintf("Rate limit exceeded. Retry in %d seconds.", ceilSeconds(tightest.RetryAfter)),
+					"RATE_LIMITED")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func refund(ctx context.Context, store Store, taken []bucket) {
+	for _, b := range taken {
+		if err := store.Refund(ctx, b.key, b.limit); err != nil {
+			slog.Warn("failed to refund rate limit token",
+				"key", b.key,
+				"error", err)
+		}
+	}
+}
+
+// DatabaseKey is the key of the per-database bucket of dbID in a route
+// group, for callers that take tokens without Middleware.
+func DatabaseKey(group, dbID string) string {
+	return group + ":database:" + dbID
+}
+
+// setHeaders writes the RateLimit-* headers from the IETF draft.
+func setHeaders(w http.ResponseWriter, d Decision) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientKey identifies the caller: the authenticated subject when there is
+// one, so all requests with one API key share a bucket, otherwise the
+// client IP (as set by middleware.RealIP).
+func clientKey(r *http.Request) string {
+	if subject := auth.SubjectFromContext(r.Context()); subject != "" {
+		return "subject:" + subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,203 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hotbrandon/go-chi/internal/auth"
+	"github.com/hotbrandon/go-chi/internal/ratelimit"
+)
+
+// ============================================================================
+// MemoryStore Tests
+// ============================================================================
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Rate: 20, Burst: 2}
+	ctx := context.Background()
+
+	for i, wantRemaining := range []int{1, 0} {
+		d, _ := store.Take(ctx, "k", limit)
+		if !d.Allowed || d.Remaining != wantRemaining {
+			t.Fatalf("request %d: expected allowed with %d remaining, got %+v", i+1, wantRemaining, d)
+		}
+	}
+
+	d, _ := store.Take(ctx, "k", limit)
+	if d.Allowed {
+		t.Fatal("expected the burst to be exhausted")
+	}
+	if d.RetryAfter <= 0 || d.RetryAfter > 50*time.Millisecond {
+		t.Errorf("expected retry after at most one token interval, got %v", d.RetryAfter)
+	}
+
+	// Other keys have their own bucket
+	if d, _ := store.Take(ctx, "other", limit); !d.Allowed {
+		t.Error("expected a separate bucket per key")
+	}
+
+	// Tokens refill at the configured rate
+	time.Sleep(60 * time.Millisecond)
+	if d, _ := store.Take(ctx, "k", limit); !d.Allowed {
+		t.Error("expected a token after refilling")
+	}
+}
+
+// ============================================================================
+// Middleware Tests
+// ============================================================================
+
+// newRouter mounts the limiter on a database route, optionally behind a
+// fake authenticator that trusts the X-Subject header
+func newRouter(store ratelimit.Store, rules ratelimit.Rules) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/api/{database_id}", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if subject := r.Header.Get("X-Subject"); subject != "" {
+					r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{Subject: subject}))
+				}
+				next.ServeHTTP(w, r)
+			})
+		})
+		r.Use(ratelimit.Middleware(store, "api", func() ratelimit.Rules { return rules }))
+		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	return r
+}
+
+func get(router http.Handler, path, subject, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":40000"
+	if subject != "" {
+		req.Header.Set("X-Subject", subject)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_PerClient(t *testing.T) {
+	router := newRouter(ratelimit.NewMemoryStore(), ratelimit.Rules{
+		PerClient: ratelimit.Limit{Rate: 1, Burst: 2},
+	})
+
+	for i := 0; i < 2; i++ {
+		w := get(router, "/api/sales/ping", "", "10.0.0.1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("expected RateLimit-Limit 2, got %q", w.Header().Get("RateLimit-Limit"))
+		}
+	}
+
+	w := get(router, "/api/sales/ping", "", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+	}
+	if w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("expected RateLimit-Remaining 0, got %q", w.Header().Get("RateLimit-Remaining"))
+	}
+
+	// Another IP, or the same IP with an identity, has its own bucket
+	if w := get(router, "/api/sales/ping", "", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("expected another client to be allowed, got %d", w.Code)
+	}
+	if w := get(router, "/api/sales/ping", "api_key:abc", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("expected an authenticated client to be keyed by subject, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_PerDatabase(t *testing.T) {
+	router := newRouter(ratelimit.NewMemoryStore(), ratelimit.Rules{
+		PerClient:   ratelimit.Limit{Rate: 100, Burst: 100},
+		PerDatabase: ratelimit.Limit{Rate: 1, Burst: 3},
+	})
+
+	// Three different clients share the database bucket
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		if w := get(router, "/api/sales/ping", "", ip); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+	w := get(router, "/api/sales/ping", "", "10.0.0.4")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the database limit to apply across clients, got %d", w.Code)
+	}
+
+	// The tighter of the two limits is reported
+	if got := w.Header().Get("RateLimit-Limit"); got != "3" {
+		t.Errorf("expected the database limit in headers, got %q", got)
+	}
+
+	// Other databases are unaffected
+	if w := get(router, "/api/finance/ping", "", "10.0.0.4"); w.Code != http.StatusOK {
+		t.Errorf("expected another database to be allowed, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_DatabaseDenialRefundsClient(t *testing.T) {
+	router := newRouter(ratelimit.NewMemoryStore(), ratelimit.Rules{
+		PerClient:   ratelimit.Limit{Rate: 0.01, Burst: 2},
+		PerDatabase: ratelimit.Limit{Rate: 0.01, Burst: 1},
+	})
+
+	// Arrange: the client uses up the database bucket, then is denied by it
+	if w := get(router, "/api/sales/ping", "", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w := get(router, "/api/sales/ping", "", "10.0.0.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the database limit to apply, got %d", w.Code)
+	}
+
+	// Act
+	w := get(router, "/api/finance/ping", "", "10.0.0.1")
+
+	// Assert: the denied request did not cost the client a token
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the client token to be refunded, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_Disabled(t *testing.T) {
+	router := newRouter(ratelimit.NewMemoryStore(), ratelimit.Rules{})
+
+	for i := 0; i < 50; i++ {
+		if w := get(router, "/api/sales/ping", "", "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 without limits, got %d", i+1, w.Code)
+		}
+	}
+}
+
+// failingStore simulates an unreachable shared backend
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("connection refused")
+}
+
+func (failingStore) Refund(ctx context.Context, key string, limit ratelimit.Limit) error {
+	return errors.New("connection refused")
+}
+
+func TestMiddleware_StoreFailureAllowsRequests(t *testing.T) {
+	router := newRouter(failingStore{}, ratelimit.Rules{
+		PerClient: ratelimit.Limit{Rate: 1, Burst: 1},
+	})
+
+	for i := 0; i < 3; i++ {
+		if w := get(router, "/api/sales/ping", "", "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 when the store fails, got %d", i+1, w.Code)
+		}
+	}
+}
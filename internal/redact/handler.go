@@ -0,0 +1,78 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Handler is a slog.Handler that scrubs every record before passing it on:
+// attributes with sensitive keys are masked entirely, and the message and
+// all other string-like values are run through the Redactor.
+type Handler struct {
+	inner    slog.Handler
+	redactor *Redactor
+}
+
+// NewHandler wraps inner. redactor may be nil to apply the patterns only.
+func NewHandler(inner slog.Handler, redactor *Redactor) *Handler {
+	return &Handler{inner: inner, redactor: redactor}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	scrubbed := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		scrubbed.AddAttrs(h.scrubAttr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, scrubbed)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		scrubbed[i] = h.scrubAttr(a)
+	}
+	return &Handler{inner: h.inner.WithAttrs(scrubbed), redactor: h.redactor}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name), redactor: h.redactor}
+}
+
+func (h *Handler) scrubAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if IsSensitiveKey(a.Key) && a.Value.Kind() != slog.KindGroup {
+		return slog.String(a.Key, Mask)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(h.redactor.String(a.Value.String()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		scrubbed := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			scrubbed[i] = h.scrubAttr(ga)
+		}
+		a.Value = slog.GroupValue(scrubbed...)
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			a.Value = slog.StringValue(h.redactor.String(v.Error()))
+		default:
+			// Keep the original value (and its JSON shape) unless its
+			// text form actually contains something to redact
+			text := fmt.Sprint(v)
+			if scrubbed := h.redactor.String(text); scrubbed != text {
+				a.Value = slog.StringValue(scrubbed)
+			}
+		}
+	}
+	return a
+}
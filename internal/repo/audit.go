@@ -0,0 +1,236 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Operations recorded in the audit trail
+const (
+	AuditCreate = "CREATE"
+	AuditUpdate = "UPDATE"
+	AuditDelete = "DELETE"
+	AuditImport = "IMPORT" // created by a CSV import
+)
+
+// AuditOperations are the operations recorded in the audit trail.
+var AuditOperations = []string{AuditCreate, AuditUpdate, AuditDelete, AuditImport}
+
+// Audit identifies who makes the changes audited by a repository.
+type Audit struct {
+	Actor      string
+	RequestID  string // Empty outside an HTTP request
+	DatabaseID string
+}
+
+// AuditEntry records one change to a transaction, with the transaction as
+// it was before (nil for creates) and after (nil for deletes).
+type AuditEntry struct {
+	AuditSeq        int             `json:"audit_seq"`
+	TransactionsSeq int             `json:"transactions_seq"`
+	Operation       string          `json:"operation"`
+	Actor           string          `json:"actor"`
+	RequestID       *string         `json:"request_id"`
+	DatabaseID      string          `json:"database_id"`
+	Before          json.RawMessage `json:"before"`
+	After           json.RawMessage `json:"after"`
+	ChangedAt       string          `json:"changed_at"`
+}
+
+// AuditFilter narrows ListAudit. Zero fields match everything.
+type AuditFilter struct {
+	TransactionID int
+	Operation     string
+	Actor         string
+	RequestID     string
+	From          string // YYYY-MM-DD, inclusive
+	Until         string // YYYY-MM-DD, exclusive
+}
+
+// auditColumns is the select list of an audit entry
+func (r *Repository) auditColumns() string {
+	return `
+			audit_seq,
+			transactions_seq,
+			operation,
+			actor,
+			request_id,
+			database_id,
+			before_data,
+			after_data,
+			` + r.dialect.DateTimeText("changed_at") + ` AS changed_at`
+}
+
+// TransactionHistory returns the audit entries of transaction id, oldest
+// first.
+func (r *Repository) TransactionHistory(ctx context.Context, id int) ([]AuditEntry, error) {
+	rows, err := r.queryContext(ctx, `
+		SELECT`+r.auditColumns()+`
+		FROM transactions_audit
+		WHERE transactions_seq = :1
+		ORDER BY audit_seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+// ListAudit returns the audit entries matching filter, newest first.
+func (r *Repository) ListAudit(ctx context.Context, filter AuditFilter, page, pageSize int) ([]AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf(":%d", len(args))))
+	}
+	if filter.TransactionID != 0 {
+		where("transactions_seq = ?", filter.TransactionID)
+	}
+	if filter.Operation != "" {
+		where("operation = ?", filter.Operation)
+	}
+	if filter.Actor != "" {
+		where("actor = ?", filter.Actor)
+	}
+	if filter.RequestID != "" {
+		where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != "" {
+		where("changed_at >= "+r.dialect.ToDate("?"), filter.From)
+	}
+	if filter.Until != "" {
+		where("changed_at < "+r.dialect.ToDate("?"), filter.Until)
+	}
+
+	query := `
+		SELECT` + r.auditColumns() + `
+		FROM transactions_audit`
+	if len(conditions) > 0 {
+		query += `
+		WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query, args = r.dialect.Paginate(
+		"audit_seq, transactions_seq, operation, actor, request_id, database_id, before_data, after_data, changed_at",
+		query+`
+		ORDER BY audit_seq DESC`,
+		args, (page-1)*pageSize, pageSize)
+
+	rows, err := r.queryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+func scanAuditEntries(rows *tracedRows) ([]AuditEntry, error) {
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var before, after *string
+		if err := rows.Scan(
+			&e.AuditSeq,
+			&e.TransactionsSeq,
+			&e.Operation,
+			&e.Actor,
+			&e.RequestID,
+			&e.DatabaseID,
+			&before,
+			&after,
+			&e.ChangedAt); err != nil {
+			return nil, err
+		}
+		if before != nil {
+			e.Before = json.RawMessage(*before)
+		}
+		if after != nil {
+			e.After = json.RawMessage(*after)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// auditedBefore reads transaction id ahead of an audited change; it
+// returns nil if changes are not audited, and ErrNotFound if there is no
+// such transaction.
+func (r *Repository) auditedBefore(ctx context.Context, id int) (*Transaction, error) {
+	if r.audit == nil {
+		return nil, nil
+	}
+	t, err := r.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// recordAudit records a change to transaction id, made by the repository's
+// Audit, if any. The transaction after the change is read back, unless it
+// was deleted.
+func (r *Repository) recordAudit(ctx context.Context, operation string, id int, before *Transaction) error {
+	if r.audit == nil {
+		return nil
+	}
+
+	var beforeData, afterData *string
+	if before != nil {
+		data, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		beforeData = stringPtr(string(data))
+	}
+	if operation != AuditDelete {
+		after, err := r.GetTransaction(ctx, id)
+		if err != nil {
+			return fmt.Errorf("audit %s: %w", strings.ToLower(operation), err)
+		}
+		data, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		afterData = stringPtr(string(data))
+	}
+
+	var requestID *string
+	if r.audit.RequestID != "" {
+		requestID = stringPtr(r.audit.RequestID)
+	}
+	_, err := r.execContext(ctx, `
+		INSERT INTO TRANSACTIONS_AUDIT (
+			AUDIT_SEQ,
+			TRANSACTIONS_SEQ,
+			OPERATION,
+			ACTOR,
+			REQUEST_ID,
+			DATABASE_ID,
+			BEFORE_DATA,
+			AFTER_DATA
+		) VALUES (
+			`+r.dialect.NextValue("TRANSACTIONS_AUDIT_SEQ")+`, :1, :2, :3, :4, :5, :6, :7
+		)`,
+		id, operation, r.audit.Actor, requestID, r.audit.DatabaseID, beforeData, afterData)
+	return err
+}
+
+// insertReturningID runs an INSERT and returns the id it assigned to
+// column. Oracle returns it into an out bind, the others as a row.
+func (r *Repository) insertReturningID(ctx context.Context, query, column string, args ...interface{}) (int, error) {
+	var id int64
+	if r.dialect.Name() == "oracle" {
+		query = fmt.Sprintf("%s RETURNING %s INTO :%d", query, column, len(args)+1)
+		_, err := r.execContext(ctx, query, append(args, sql.Out{Dest: &id})...)
+		return int(id), err
+	}
+	err := r.queryRowContext(ctx, query+" RETURNING "+column, args...).Scan(&id)
+	return int(id), err
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,154 @@
+package repo_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// ============================================================================
+// Audit Trail Tests
+// ============================================================================
+
+func TestAudit_RecordsEveryChange(t *testing.T) {
+	// Arrange
+	repository := newSQLiteRepository(t)
+	ctx := context.Background()
+	tx, err := repository.BeginTx(ctx, sql.LevelSerializable)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	defer tx.Rollback()
+	audited := repository.WithTx(tx).WithAudit(repo.Audit{Actor: "alice", RequestID: "req-1", DatabaseID: "sales"})
+	created := repo.Transaction{CoinSymbol: "BTC", TransactionType: "B", Quantity: 1, PricePerUnit: 100,
+		TotalCost: 100, TransactionDate: "2024-01-15", Exchange: "BN"}
+
+	// Act
+	if err := audited.CreateTransaction(ctx, created); err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+	updated := created
+	updated.TransactionsSeq, updated.Quantity = 1, 2
+	if err := audited.UpdateTransaction(ctx, updated); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if err := audited.DeleteTransaction(ctx, 1); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if err := audited.ImportTransaction(ctx, created); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	missing := audited.UpdateTransaction(ctx, repo.Transaction{TransactionsSeq: 99, TransactionDate: "2024-01-15"})
+	history, err := audited.TransactionHistory(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// Assert
+	if !errors.Is(missing, repo.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing transaction, got %v", missing)
+	}
+	// SQLite reuses the id of the deleted row for the import
+	if len(history) != 4 {
+		t.Fatalf("expected 4 entries for id 1, got %+v", history)
+	}
+	for i, want := range []string{repo.AuditCreate, repo.AuditUpdate, repo.AuditDelete, repo.AuditImport} {
+		e := history[i]
+		if e.Operation != want || e.Actor != "alice" || e.RequestID == nil || *e.RequestID != "req-1" || e.DatabaseID != "sales" || e.ChangedAt == "" {
+			t.Errorf("entry %d: expected %s by alice in req-1, got %+v", i, want, e)
+		}
+	}
+
+	var before, after repo.Transaction
+	json.Unmarshal(history[1].Before, &before)
+	json.Unmarshal(history[1].After, &after)
+	if before.Quantity != 1 || after.Quantity != 2 || after.TransactionsSeq != 1 {
+		t.Errorf("expected the update from 1 to 2, got %+v -> %+v", before, after)
+	}
+	if history[0].Before != nil || history[2].After != nil {
+		t.Errorf("expected no before for creates and no after for deletes, got %+v", history)
+	}
+}
+
+func TestAudit_RolledBackWithTheChange(t *testing.T) {
+	// Arrange
+	repository := newSQLiteRepository(t)
+	ctx := context.Background()
+	tx, err := repository.BeginTx(ctx, sql.LevelSerializable)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+
+	// Act
+	err = repository.WithTx(tx).WithAudit(repo.Audit{Actor: "alice", DatabaseID: "sales"}).CreateTransaction(ctx,
+		repo.Transaction{CoinSymbol: "BTC", TransactionType: "B", Quantity: 1, PricePerUnit: 100,
+			TotalCost: 100, TransactionDate: "2024-01-15", Exchange: "BN"})
+	tx.Rollback()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+	if entries, _ := repository.ListAudit(ctx, repo.AuditFilter{}, 1, 10); len(entries) != 0 {
+		t.Errorf("expected the entry to be rolled back, got %+v", entries)
+	}
+}
+
+func TestAudit_ListFilters(t *testing.T) {
+	// Arrange
+	repository := newSQLiteRepository(t)
+	ctx := context.Background()
+	for _, actor := range []string{"alice", "bob", "alice"} {
+		audited := repository.WithAudit(repo.Audit{Actor: actor, DatabaseID: "sales"})
+		if err := audited.CreateTransaction(ctx, repo.Transaction{CoinSymbol: "BTC", TransactionType: "B",
+			Quantity: 1, PricePerUnit: 100, TotalCost: 100, TransactionDate: "2024-01-15", Exchange: "BN"}); err != nil {
+			t.Fatalf("failed to create: %v", err)
+		}
+	}
+	today := time.Now().UTC().Format(time.DateOnly)
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+
+	tests := []struct {
+		name   string
+		filter repo.AuditFilter
+		want   []int // audit_seq, newest first
+	}{
+		{"all", repo.AuditFilter{}, []int{3, 2, 1}},
+		{"actor", repo.AuditFilter{Actor: "alice"}, []int{3, 1}},
+		{"transaction", repo.AuditFilter{TransactionID: 2}, []int{2}},
+		{"operation", repo.AuditFilter{Operation: repo.AuditDelete}, nil},
+		{"today", repo.AuditFilter{From: today, Until: tomorrow, Actor: "bob"}, []int{2}},
+		{"before today", repo.AuditFilter{Until: today}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			entries, err := repository.ListAudit(ctx, tt.filter, 1, 10)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []int
+			for _, e := range entries {
+				got = append(got, e.AuditSeq)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if page, _ := repository.ListAudit(ctx, repo.AuditFilter{}, 2, 2); len(page) != 1 || page[0].AuditSeq != 1 {
+		t.Errorf("expected the oldest entry on page 2, got %+v", page)
+	}
+}
@@ -2,40 +2,39 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 )
 
+// transactionColumns is the select list of a transaction, with dates as
+// ISO 8601 text
+func (r *Repository) transactionColumns() string {
+	return `
+			transactions_seq,
+			coin_symbol,
+			transaction_type,
+			quantity,
+			price_per_unit,
+			total_cost,
+			` + r.dialect.DateTimeText("transaction_date") + ` AS transaction_date,
+			exchange,
+			notes,
+			` + r.dialect.DateTimeText("created_at") + ` AS created_at`
+}
+
 func (r *Repository) ListTransactions(ctx context.Context, page, pageSize int) ([]Transaction, error) {
 	var transactions []Transaction
 
-	startRow := (page - 1) * pageSize
-	endRow := page * pageSize
-
-	// This query uses ROWNUM for pagination, which is compatible with Oracle 11gR2.
 	// ORDER BY is crucial for stable pagination results.
-	query := `
-		SELECT transactions_seq, coin_symbol, transaction_type, quantity, price_per_unit, total_cost, transaction_date, exchange, notes, created_at
-		FROM (
-			SELECT t.*, ROWNUM rnum
-			FROM (
-				SELECT
-					transactions_seq,
-					coin_symbol,
-					transaction_type,
-					quantity,
-					price_per_unit,
-					total_cost,
-					TO_CHAR(transaction_date, 'YYYY-MM-DD"T"HH24:MI:SS') AS transaction_date,
-					exchange,
-					notes,
-					TO_CHAR(created_at, 'YYYY-MM-DD"T"HH24:MI:SS') AS created_at
-				FROM transactions
-				ORDER BY transaction_date DESC, transactions_seq DESC
-			) t
-			WHERE ROWNUM <= :1
-		)
-		WHERE rnum > :2`
-
-	rows, err := r.db.QueryContext(ctx, query, endRow, startRow)
+	query, args := r.dialect.Paginate(
+		"transactions_seq, coin_symbol, transaction_type, quantity, price_per_unit, total_cost, transaction_date, exchange, notes, created_at",
+		`
+		SELECT`+r.transactionColumns()+`
+		FROM transactions
+		ORDER BY transaction_date DESC, transactions_seq DESC`,
+		nil, (page-1)*pageSize, pageSize)
+
+	rows, err := r.queryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +62,17 @@ func (r *Repository) ListTransactions(ctx context.Context, page, pageSize int) (
 }
 
 func (r *Repository) CreateTransaction(ctx context.Context, t Transaction) error {
-	_, err := r.db.ExecContext(ctx, `
+	return r.createTransaction(ctx, t, AuditCreate)
+}
+
+// ImportTransaction is CreateTransaction for a row of an import, which the
+// audit trail tells apart.
+func (r *Repository) ImportTransaction(ctx context.Context, t Transaction) error {
+	return r.createTransaction(ctx, t, AuditImport)
+}
+
+func (r *Repository) createTransaction(ctx context.Context, t Transaction, operation string) error {
+	query := `
 		INSERT INTO TRANSACTIONS (
 			TRANSACTIONS_SEQ,
 			COIN_SYMBOL,
@@ -75,9 +84,124 @@ func (r *Repository) CreateTransaction(ctx context.Context, t Transaction) error
 			EXCHANGE,
 			NOTES
 		) VALUES (
-			TRANSACTIONS_SEQ.NEXTVAL, :1, :2, :3, :4, :5, TO_DATE(:6, 'YYYY-MM-DD'), :7, :8
-		)`,
-		t.CoinSymbol, t.TransactionType, t.Quantity, t.PricePerUnit, t.TotalCost, t.TransactionDate, t.Exchange, t.Notes)
+			` + r.dialect.NextValue("TRANSACTIONS_SEQ") + `, :1, :2, :3, :4, :5, ` + r.dialect.ToDate(":6") + `, :7, :8
+		)`
+	args := []interface{}{t.CoinSymbol, t.TransactionType, t.Quantity, t.PricePerUnit, t.TotalCost, t.TransactionDate, t.Exchange, t.Notes}
+
+	// The audit trail needs the id assigned
+	if r.audit == nil {
+		_, err := r.execContext(ctx, query, args...)
+		return err
+	}
+	id, err := r.insertReturningID(ctx, query, "TRANSACTIONS_SEQ", args...)
+	if err != nil {
+		return err
+	}
+	return r.recordAudit(ctx, operation, id, nil)
+}
+
+func (r *Repository) GetTransaction(ctx context.Context, id int) (Transaction, error) {
+	var t Transaction
+	err := r.queryRowContext(ctx, `
+		SELECT`+r.transactionColumns()+`
+		FROM transactions
+		WHERE transactions_seq = :1`, id).Scan(
+		&t.TransactionsSeq,
+		&t.CoinSymbol,
+		&t.TransactionType,
+		&t.Quantity,
+		&t.PricePerUnit,
+		&t.TotalCost,
+		&t.TransactionDate,
+		&t.Exchange,
+		&t.Notes,
+		&t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Transaction{}, ErrNotFound
+	}
+	return t, err
+}
+
+func (r *Repository) UpdateTransaction(ctx context.Context, t Transaction) error {
+	before, err := r.auditedBefore(ctx, t.TransactionsSeq)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.execContext(ctx, `
+		UPDATE TRANSACTIONS SET
+			COIN_SYMBOL = :1,
+			TRANSACTION_TYPE = :2,
+			QUANTITY = :3,
+			PRICE_PER_UNIT = :4,
+			TOTAL_COST = :5,
+			TRANSACTION_DATE = `+r.dialect.ToDate(":6")+`,
+			EXCHANGE = :7,
+			NOTES = :8
+		WHERE TRANSACTIONS_SEQ = :9`,
+		t.CoinSymbol, t.TransactionType, t.Quantity, t.PricePerUnit, t.TotalCost, t.TransactionDate, t.Exchange, t.Notes,
+		t.TransactionsSeq)
+	if err != nil {
+		return err
+	}
+	if err := requireRow(result); err != nil {
+		return err
+	}
+	return r.recordAudit(ctx, AuditUpdate, t.TransactionsSeq, before)
+}
+
+func (r *Repository) DeleteTransaction(ctx context.Context, id int) error {
+	before, err := r.auditedBefore(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.execContext(ctx, `DELETE FROM TRANSACTIONS WHERE TRANSACTIONS_SEQ = :1`, id)
+	if err != nil {
+		return err
+	}
+	if err := requireRow(result); err != nil {
+		return err
+	}
+	return r.recordAudit(ctx, AuditDelete, id, before)
+}
+
+// Holdings returns the position in each coin, sorted by symbol.
+func (r *Repository) Holdings(ctx context.Context) ([]Holding, error) {
+	rows, err := r.queryContext(ctx, `
+		SELECT
+			coin_symbol,
+			SUM(CASE WHEN transaction_type = 'B' THEN quantity ELSE -quantity END),
+			SUM(CASE WHEN transaction_type = 'B' THEN total_cost ELSE 0 END),
+			SUM(CASE WHEN transaction_type = 'S' THEN total_cost ELSE 0 END),
+			COUNT(*)
+		FROM transactions
+		GROUP BY coin_symbol
+		ORDER BY coin_symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holdings := []Holding{}
+	for rows.Next() {
+		var h Holding
+		if err := rows.Scan(&h.CoinSymbol, &h.Quantity, &h.TotalBought, &h.TotalSold, &h.Transactions); err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, h)
+	}
+	return holdings, rows.Err()
+}
 
-	return err
+// requireRow maps an update or delete that matched nothing to ErrNotFound
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
@@ -12,3 +12,12 @@ type Transaction struct {
 	Notes           *string `json:"notes"`
 	CreatedAt       string  `json:"created_at"`
 }
+
+// Holding is the position in one coin: what was bought less what was sold.
+type Holding struct {
+	CoinSymbol   string  `json:"coin_symbol"`
+	Quantity     float64 `json:"quantity"`
+	TotalBought  float64 `json:"total_bought"` // total cost of buys
+	TotalSold    float64 `json:"total_sold"`   // total cost of sells
+	Transactions int     `json:"transactions"`
+}
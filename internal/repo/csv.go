@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSV columns, matching the JSON field names. transactions_seq and
+// created_at are written on export and ignored on import.
+var csvColumns = []string{
+	"transactions_seq",
+	"coin_symbol",
+	"transaction_type",
+	"quantity",
+	"price_per_unit",
+	"total_cost",
+	"transaction_date",
+	"exchange",
+	"notes",
+	"created_at",
+}
+
+var requiredCSVColumns = []string{
+	"coin_symbol",
+	"transaction_type",
+	"quantity",
+	"price_per_unit",
+	"total_cost",
+	"transaction_date",
+	"exchange",
+}
+
+// ReadTransactionsCSV parses transactions from CSV with a header row. Columns
+// are matched by name, so their order does not matter and unknown columns
+// are ignored. Errors name the offending line.
+func ReadTransactionsCSV(r io.Reader) ([]Transaction, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv: missing header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredCSVColumns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("csv: missing column %q", name)
+		}
+	}
+
+	var transactions []Transaction
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(name string) (float64, error) {
+			v, err := strconv.ParseFloat(field(name), 64)
+			if err != nil {
+				return 0, fmt.Errorf("csv line %d: invalid %s %q", line, name, field(name))
+			}
+			return v, nil
+		}
+
+		t := Transaction{
+			CoinSymbol:      field("coin_symbol"),
+			TransactionType: field("transaction_type"),
+			TransactionDate: field("transaction_date"),
+			Exchange:        field("exchange"),
+		}
+		if t.Quantity, err = number("quantity"); err != nil {
+			return nil, err
+		}
+		if t.PricePerUnit, err = number("price_per_unit"); err != nil {
+			return nil, err
+		}
+		if t.TotalCost, err = number("total_cost"); err != nil {
+			return nil, err
+		}
+		// Exports carry a time of day; only the date is stored
+		t.TransactionDate, _, _ = strings.Cut(field("transaction_date"), "T")
+		if notes := field("notes"); notes != "" {
+			t.Notes = &notes
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+// WriteTransactionsCSV writes transactions as CSV with a header row, in the
+// format read by ReadTransactionsCSV.
+func WriteTransactionsCSV(w io.Writer, transactions []Transaction) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		notes := ""
+		if t.Notes != nil {
+			notes = *t.Notes
+		}
+		if err := writer.Write([]string{
+			strconv.Itoa(t.TransactionsSeq),
+			t.CoinSymbol,
+			t.TransactionType,
+			strconv.FormatFloat(t.Quantity, 'f', -1, 64),
+			strconv.FormatFloat(t.PricePerUnit, 'f', -1, 64),
+			strconv.FormatFloat(t.TotalCost, 'f', -1, 64),
+			t.TransactionDate,
+			t.Exchange,
+			notes,
+			t.CreatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
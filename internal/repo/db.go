@@ -3,8 +3,14 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"sync/atomic"
 )
 
+// ErrNotFound is returned when a row looked up, updated or deleted by id
+// does not exist.
+var ErrNotFound = errors.New("not found")
+
 // DBTX is an interface that wraps the basic methods of *sql.DB and *sql.Tx
 // to allow for using either in the Repository struct.
 type DBTX interface {
@@ -15,15 +21,56 @@ type DBTX interface {
 }
 
 type Repository struct {
-	db DBTX
+	db         DBTX
+	reader     DBTX // Runs the queries; db, unless reads go to a replica
+	dialect    Dialect
+	conflicted *atomic.Bool // Set in a transaction, see Conflicted
+	audit      *Audit       // Set to audit changes, see WithAudit
+}
+
+// New returns a repository running its statements on db in the given
+// dialect.
+func New(db DBTX, dialect Dialect) *Repository {
+	return &Repository{db: db, reader: db, dialect: dialect}
 }
 
-func New(db DBTX) *Repository {
-	return &Repository{db: db}
+// WithReader returns a repository running its queries (lists, lookups and
+// reports) on reader, such as a read replica, and its writes on the
+// current database.
+func (r *Repository) WithReader(reader DBTX) *Repository {
+	return &Repository{
+		db:      r.db,
+		reader:  reader,
+		dialect: r.dialect,
+	}
 }
 
+// WithTx returns a repository running everything, reads included, in tx,
+// instrumented like r.
 func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	conflicted := new(atomic.Bool)
+	var db DBTX = conflictObserver{DBTX: tx, conflicted: conflicted}
+	if instrumented, ok := r.db.(*instrumentedDB); ok {
+		db = Instrument(db, instrumented.stats)
+	}
+	return &Repository{
+		db:         db,
+		reader:     db,
+		dialect:    r.dialect,
+		conflicted: conflicted,
+	}
+}
+
+// WithAudit returns a repository recording who makes each change it makes
+// to TRANSACTIONS in TRANSACTIONS_AUDIT (see AuditEntry). Its queries run
+// on the current database, so that they see its changes; call it on a
+// repository returned by WithTx for the entries to be committed with them.
+func (r *Repository) WithAudit(audit Audit) *Repository {
 	return &Repository{
-		db: tx,
+		db:         r.db,
+		reader:     r.db,
+		dialect:    r.dialect,
+		conflicted: r.conflicted,
+		audit:      &audit,
 	}
 }
@@ -0,0 +1,204 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dialect hides the SQL differences between the supported databases.
+// Repository queries are written for Oracle, with :1-style placeholders,
+// and passed through the dialect of the database they run against.
+type Dialect interface {
+	// Name is the driver setting that selects the dialect ("oracle")
+	Name() string
+	// DriverName is the database/sql driver to open
+	DriverName() string
+	// Rebind rewrites :1-style placeholders into the dialect's own
+	Rebind(query string) string
+	// Paginate limits query to limit rows after offset, appending the bind
+	// values it needs to args. The query must be ordered; columns lists
+	// its result columns, which the paginated query returns.
+	Paginate(columns, query string, args []interface{}, offset, limit int) (string, []interface{})
+	// NextValue is the expression inserted into an id column to take the
+	// next value of sequence
+	NextValue(sequence string) string
+	// ToDate converts a YYYY-MM-DD bind value to a date
+	ToDate(expr string) string
+	// DateTimeText formats a date column as YYYY-MM-DDTHH:MI:SS
+	DateTimeText(expr string) string
+}
+
+// Supported dialects, keyed by name
+var dialects = map[string]Dialect{
+	"oracle":   Oracle,
+	"postgres": Postgres,
+	"sqlite":   SQLite,
+}
+
+// DialectNames lists the supported dialects.
+var DialectNames = []string{"oracle", "postgres", "sqlite"}
+
+// LookupDialect returns the dialect called name.
+func LookupDialect(name string) (Dialect, error) {
+	d, ok := dialects[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown database driver %q (expected one of %v)", name, DialectNames)
+	}
+	return d, nil
+}
+
+var (
+	// Oracle 11gR2 and later, through go-ora
+	Oracle Dialect = oracleDialect{}
+	// Oracle 12c and later, which paginate with OFFSET/FETCH
+	Oracle12c Dialect = oracleDialect{offsetFetch: true}
+	// PostgreSQL, through pgx
+	Postgres Dialect = postgresDialect{}
+	// SQLite, through the cgo-free modernc.org/sqlite
+	SQLite Dialect = sqliteDialect{}
+)
+
+type oracleDialect struct {
+	// Whether the server supports OFFSET/FETCH (12c and later)
+	offsetFetch bool
+}
+
+func (oracleDialect) Name() string               { return "oracle" }
+func (oracleDialect) DriverName() string         { return "oracle" }
+func (oracleDialect) Rebind(query string) string { return query }
+
+// Paginate uses OFFSET/FETCH from 12c, and ROWNUM before. The row number
+// column is left out of the result.
+func (d oracleDialect) Paginate(columns, query string, args []interface{}, offset, limit int) (string, []interface{}) {
+	n := len(args)
+	if d.offsetFetch {
+		return fmt.Sprintf("%s\n\t\tOFFSET :%d ROWS FETCH NEXT :%d ROWS ONLY", query, n+1, n+2),
+			append(args, offset, limit)
+	}
+	return fmt.Sprintf(`
+		SELECT %s
+		FROM (
+			SELECT t.*, ROWNUM rnum
+			FROM (%s) t
+			WHERE ROWNUM <= :%d
+		)
+		WHERE rnum > :%d`, columns, query, n+1, n+2), append(args, offset+limit, offset)
+}
+
+func (oracleDialect) NextValue(sequence string) string { return sequence + ".NEXTVAL" }
+func (oracleDialect) ToDate(expr string) string        { return "TO_DATE(" + expr + ", 'YYYY-MM-DD')" }
+func (oracleDialect) DateTimeText(expr string) string {
+	return "TO_CHAR(" + expr + `, 'YYYY-MM-DD"T"HH24:MI:SS')`
+}
+
+// oracleRelease finds the version in a V$VERSION banner, e.g. "Oracle
+// Database 19c Enterprise Edition Release 19.0.0.0.0 - Production"
+var oracleRelease = regexp.MustCompile(`Release (\d+)(\.\d+)*`)
+
+// DetectOracle asks the server db is connected to for its version and
+// returns it with the matching dialect.
+func DetectOracle(ctx context.Context, db DBTX) (Dialect, string, error) {
+	var banner string
+	err := db.QueryRowContext(ctx,
+		`SELECT BANNER FROM V$VERSION WHERE BANNER LIKE 'Oracle%'`).Scan(&banner)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errors.New("no Oracle banner in V$VERSION")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("detect Oracle version: %w", err)
+	}
+	match := oracleRelease.FindString(banner)
+	if match == "" {
+		return nil, "", fmt.Errorf("detect Oracle version: unrecognised banner %q", banner)
+	}
+	version := strings.TrimPrefix(match, "Release ")
+	return OracleFor(version), version, nil
+}
+
+// OracleFor returns the dialect for an Oracle release such as "19.0.0.0.0".
+// Unparseable versions get the 11gR2 dialect, which works everywhere.
+func OracleFor(version string) Dialect {
+	major, _, _ := strings.Cut(version, ".")
+	if n, err := strconv.Atoi(major); err == nil && n >= 12 {
+		return Oracle12c
+	}
+	return Oracle
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string               { return "postgres" }
+func (postgresDialect) DriverName() string         { return "pgx" }
+func (postgresDialect) Rebind(query string) string { return rebind(query, "$") }
+
+func (postgresDialect) Paginate(_, query string, args []interface{}, offset, limit int) (string, []interface{}) {
+	return limitOffset(query, args, offset, limit)
+}
+
+func (postgresDialect) NextValue(sequence string) string {
+	return "nextval('" + strings.ToLower(sequence) + "')"
+}
+func (postgresDialect) ToDate(expr string) string { return "TO_DATE(" + expr + ", 'YYYY-MM-DD')" }
+func (postgresDialect) DateTimeText(expr string) string {
+	return "TO_CHAR(" + expr + `, 'YYYY-MM-DD"T"HH24:MI:SS')`
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string               { return "sqlite" }
+func (sqliteDialect) DriverName() string         { return "sqlite" }
+func (sqliteDialect) Rebind(query string) string { return rebind(query, "?") }
+
+func (sqliteDialect) Paginate(_, query string, args []interface{}, offset, limit int) (string, []interface{}) {
+	return limitOffset(query, args, offset, limit)
+}
+
+// NextValue inserts NULL, for which an INTEGER PRIMARY KEY column takes the
+// next rowid; SQLite has no sequences
+func (sqliteDialect) NextValue(string) string   { return "NULL" }
+func (sqliteDialect) ToDate(expr string) string { return "date(" + expr + ")" }
+func (sqliteDialect) DateTimeText(expr string) string {
+	return "strftime('%Y-%m-%dT%H:%M:%S', " + expr + ")"
+}
+
+func limitOffset(query string, args []interface{}, offset, limit int) (string, []interface{}) {
+	n := len(args)
+	return fmt.Sprintf("%s\n\t\tLIMIT :%d OFFSET :%d", query, n+1, n+2), append(args, limit, offset)
+}
+
+// rebind replaces :1-style placeholders outside string literals and quoted
+// identifiers with prefix followed by the same number ($1 or ?1)
+func rebind(query, prefix string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ':' && i+1 < len(query) && isDigit(query[i+1]):
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			b.WriteString(prefix)
+			b.WriteString(query[i+1 : j])
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
@@ -0,0 +1,320 @@
+package repo_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/hotbrandon/go-chi/internal/migrate"
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// ============================================================================
+// Dialect Tests
+// ============================================================================
+
+func TestDialect_Rebind(t *testing.T) {
+	query := `SELECT ':1', ":2" FROM t WHERE a = :1 AND b = :12`
+
+	tests := []struct {
+		dialect repo.Dialect
+		want    string
+	}{
+		{repo.Oracle, query},
+		{repo.Postgres, `SELECT ':1', ":2" FROM t WHERE a = $1 AND b = $12`},
+		{repo.SQLite, `SELECT ':1', ":2" FROM t WHERE a = ?1 AND b = ?12`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			if got := tt.dialect.Rebind(query); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDialect_Paginate(t *testing.T) {
+	const query = "SELECT id FROM t WHERE a = :1 ORDER BY id"
+
+	tests := []struct {
+		name     string
+		dialect  repo.Dialect
+		contains []string
+		args     []interface{}
+	}{
+		{
+			name:     "oracle 11g",
+			dialect:  repo.Oracle,
+			contains: []string{"SELECT id\n", "ROWNUM <= :2", "rnum > :3"},
+			args:     []interface{}{"x", 30, 20},
+		},
+		{
+			name:     "oracle 12c",
+			dialect:  repo.Oracle12c,
+			contains: []string{query + "\n", "OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY"},
+			args:     []interface{}{"x", 20, 10},
+		},
+		{
+			name:     "postgres",
+			dialect:  repo.Postgres,
+			contains: []string{"LIMIT :2 OFFSET :3"},
+			args:     []interface{}{"x", 10, 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, args := tt.dialect.Paginate("id", query, []interface{}{"x"}, 20, 10)
+
+			// Assert
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in %q", want, got)
+				}
+			}
+			if tt.dialect == repo.Oracle12c && strings.Contains(got, "ROWNUM") {
+				t.Errorf("expected no ROWNUM on 12c, got %q", got)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("expected args %v, got %v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestOracleFor(t *testing.T) {
+	tests := []struct {
+		version string
+		want    repo.Dialect
+	}{
+		{"11.2.0.4.0", repo.Oracle},
+		{"12.1.0.2.0", repo.Oracle12c},
+		{"19.0.0.0.0", repo.Oracle12c},
+		{"23.0.0.0.0", repo.Oracle12c},
+		{"unknown", repo.Oracle},
+	}
+
+	for _, tt := range tests {
+		if got := repo.OracleFor(tt.version); got != tt.want {
+			t.Errorf("%s: expected %#v, got %#v", tt.version, tt.want, got)
+		}
+	}
+}
+
+// bannerConnector answers every query with one V$VERSION banner
+type bannerConnector struct{ banner string }
+
+func (c bannerConnector) Connect(context.Context) (driver.Conn, error) { return bannerConn(c), nil }
+func (c bannerConnector) Driver() driver.Driver                        { return nil }
+
+type bannerConn struct{ banner string }
+
+func (bannerConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (bannerConn) Close() error                        { return nil }
+func (bannerConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c bannerConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &bannerRows{banner: c.banner}, nil
+}
+
+type bannerRows struct {
+	banner string
+	done   bool
+}
+
+func (r *bannerRows) Columns() []string { return []string{"BANNER"} }
+func (r *bannerRows) Close() error      { return nil }
+func (r *bannerRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.banner
+	return nil
+}
+
+func TestDetectOracle(t *testing.T) {
+	tests := []struct {
+		banner  string
+		want    repo.Dialect
+		version string
+	}{
+		{"Oracle Database 11g Enterprise Edition Release 11.2.0.4.0 - 64bit Production", repo.Oracle, "11.2.0.4.0"},
+		{"Oracle Database 19c Enterprise Edition Release 19.0.0.0.0 - Production", repo.Oracle12c, "19.0.0.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			// Arrange
+			db := sql.OpenDB(bannerConnector{tt.banner})
+			defer db.Close()
+
+			// Act
+			d, version, err := repo.DetectOracle(context.Background(), db)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d != tt.want || version != tt.version {
+				t.Errorf("expected %#v %s, got %#v %s", tt.want, tt.version, d, version)
+			}
+		})
+	}
+
+	db := sql.OpenDB(bannerConnector{"PL/SQL Release"})
+	defer db.Close()
+	if _, _, err := repo.DetectOracle(context.Background(), db); err == nil {
+		t.Error("expected an error for an unrecognised banner")
+	}
+}
+
+func TestLookupDialect(t *testing.T) {
+	for _, name := range repo.DialectNames {
+		if d, err := repo.LookupDialect(name); err != nil || d.Name() != name {
+			t.Errorf("expected dialect %q, got %v, %v", name, d, err)
+		}
+	}
+	if _, err := repo.LookupDialect("mysql"); err == nil {
+		t.Error("expected an error for an unknown dialect")
+	}
+}
+
+// ============================================================================
+// SQLite Tests
+// ============================================================================
+
+// newSQLiteRepository returns a repository over an in-memory SQLite database
+// migrated with the embedded migrations
+func newSQLiteRepository(t *testing.T) *repo.Repository {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	// Each connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := migrate.Embedded("sqlite")
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	migrator, err := migrate.New(db, migrations, migrate.Options{Dialect: repo.SQLite, Owner: "test"})
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return repo.New(db, repo.SQLite)
+}
+
+func TestSQLite_Transactions(t *testing.T) {
+	// Arrange
+	r := newSQLiteRepository(t)
+	ctx := context.Background()
+	for _, date := range []string{"2024-01-15", "2024-03-01", "2024-02-10"} {
+		err := r.CreateTransaction(ctx, repo.Transaction{
+			CoinSymbol: "BTC", TransactionType: "B", Quantity: 0.5, PricePerUnit: 50000,
+			TotalCost: 25000, TransactionDate: date, Exchange: "BN",
+		})
+		if err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+	}
+
+	// Act
+	first, err := r.ListTransactions(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := r.ListTransactions(ctx, 2, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Assert
+	if len(first) != 2 || len(second) != 1 {
+		t.Fatalf("expected pages of 2 and 1, got %d and %d", len(first), len(second))
+	}
+	if first[0].TransactionDate != "2024-03-01T00:00:00" || second[0].TransactionDate != "2024-01-15T00:00:00" {
+		t.Errorf("expected newest first, got %q ... %q", first[0].TransactionDate, second[0].TransactionDate)
+	}
+	if second[0].TransactionsSeq != 1 || first[0].CreatedAt == "" {
+		t.Errorf("expected generated id and creation time, got %+v", second[0])
+	}
+}
+
+func TestSQLite_UpdateAndDelete(t *testing.T) {
+	// Arrange
+	r := newSQLiteRepository(t)
+	ctx := context.Background()
+	created := repo.Transaction{
+		CoinSymbol: "ETH", TransactionType: "B", Quantity: 2, PricePerUnit: 3000,
+		TotalCost: 6000, TransactionDate: "2024-01-15", Exchange: "OK",
+	}
+	if err := r.CreateTransaction(ctx, created); err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	// Act
+	updated := created
+	updated.TransactionsSeq, updated.TransactionType, updated.TransactionDate = 1, "S", "2024-02-01"
+	err := r.UpdateTransaction(ctx, updated)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := r.GetTransaction(ctx, 1)
+	if err != nil || got.TransactionType != "S" || got.TransactionDate != "2024-02-01T00:00:00" {
+		t.Errorf("expected the updated transaction, got %+v, %v", got, err)
+	}
+	if err := r.DeleteTransaction(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.GetTransaction(ctx, 1); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestSQLite_Holdings(t *testing.T) {
+	// Arrange
+	r := newSQLiteRepository(t)
+	ctx := context.Background()
+	for _, tx := range []repo.Transaction{
+		{CoinSymbol: "BTC", TransactionType: "B", Quantity: 2, PricePerUnit: 100, TotalCost: 200},
+		{CoinSymbol: "BTC", TransactionType: "S", Quantity: 0.5, PricePerUnit: 120, TotalCost: 60},
+		{CoinSymbol: "ETH", TransactionType: "B", Quantity: 3, PricePerUnit: 10, TotalCost: 30},
+	} {
+		tx.TransactionDate, tx.Exchange = "2024-01-15", "BN"
+		if err := r.CreateTransaction(ctx, tx); err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+	}
+
+	// Act
+	holdings, err := r.Holdings(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []repo.Holding{
+		{CoinSymbol: "BTC", Quantity: 1.5, TotalBought: 200, TotalSold: 60, Transactions: 2},
+		{CoinSymbol: "ETH", Quantity: 3, TotalBought: 30, Transactions: 1},
+	}
+	if !reflect.DeepEqual(holdings, want) {
+		t.Errorf("expected %+v, got %+v", want, holdings)
+	}
+}
@@ -0,0 +1,271 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/logging"
+	"github.com/hotbrandon/go-chi/internal/tracing"
+)
+
+const (
+	// Recent durations kept per statement for percentiles
+	durationWindow = 512
+	// Distinct statements tracked per database; any beyond are pooled
+	maxStatements  = 1000
+	otherStatement = "(other)"
+)
+
+// Instrument wraps db so that every statement run through it is timed into
+// stats, and statements slower than the threshold are logged.
+func Instrument(db DBTX, stats *QueryStats) DBTX {
+	return &instrumentedDB{db: db, stats: stats}
+}
+
+type instrumentedDB struct {
+	db    DBTX
+	stats *QueryStats
+}
+
+func (i *instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := i.db.ExecContext(ctx, query, args...)
+	var rows int64
+	if err == nil {
+		rows, _ = result.RowsAffected()
+	}
+	i.stats.record(ctx, query, args, time.Since(start), rows, err)
+	return result, err
+}
+
+func (i *instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return i.db.PrepareContext(ctx, query)
+}
+
+// QueryContext records the statement once its rows are closed when called
+// through Repository, which reports the rows read; otherwise it records the
+// time to the first response.
+func (i *instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	if err != nil || !onRowsDone(ctx, func(n int, rowsErr error) {
+		i.stats.record(ctx, query, args, time.Since(start), int64(n), rowsErr)
+	}) {
+		i.stats.record(ctx, query, args, time.Since(start), 0, err)
+	}
+	return rows, err
+}
+
+func (i *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := i.db.QueryRowContext(ctx, query, args...)
+	i.stats.record(ctx, query, args, time.Since(start), 0, row.Err())
+	return row
+}
+
+// conn takes a connection from the wrapped pool, keeping it instrumented
+func (i *instrumentedDB) conn(ctx context.Context) (DBTX, func(), error) {
+	db, release, err := conn(ctx, i.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &instrumentedDB{db: db, stats: i.stats}, release, nil
+}
+
+// rowsDone lets a decorator learn how many rows a query returned, which
+// QueryContext cannot tell it: Repository puts one in the query's context
+// and runs the callbacks when the rows are closed.
+type rowsDone struct {
+	callbacks []func(n int, err error)
+}
+
+type rowsDoneKey struct{}
+
+// onRowsDone registers f to run when the query's rows are closed. It
+// reports false when the caller will not report them.
+func onRowsDone(ctx context.Context, f func(n int, err error)) bool {
+	done, ok := ctx.Value(rowsDoneKey{}).(*rowsDone)
+	if ok {
+		done.callbacks = append(done.callbacks, f)
+	}
+	return ok
+}
+
+// QueryStats aggregates statement timings of one database by fingerprint:
+// the SQL with literals and whitespace normalised.
+type QueryStats struct {
+	databaseID    string
+	slowThreshold func() time.Duration
+
+	mu         sync.Mutex
+	statements map[string]*statementStats
+}
+
+type statementStats struct {
+	fingerprint string
+	statement   string
+	calls       int64
+	errors      int64
+	rows        int64
+	total       time.Duration
+	max         time.Duration
+	recent      []time.Duration // ring of the last durationWindow calls
+	next        int
+}
+
+// StatementStats is a snapshot of one statement's timings.
+type StatementStats struct {
+	Fingerprint string
+	Statement   string
+	Calls       int64
+	Errors      int64
+	Rows        int64
+	Total       time.Duration
+	Mean        time.Duration
+	P95         time.Duration // over recent calls
+	Max         time.Duration
+}
+
+// NewQueryStats returns empty statistics for databaseID. slowThreshold is
+// read on every statement, so reloads apply immediately; 0 disables the
+// slow query log.
+func NewQueryStats(databaseID string, slowThreshold func() time.Duration) *QueryStats {
+	return &QueryStats{
+		databaseID:    databaseID,
+		slowThreshold: slowThreshold,
+		statements:    make(map[string]*statementStats),
+	}
+}
+
+func (s *QueryStats) record(ctx context.Context, query string, args []interface{}, elapsed time.Duration, rows int64, err error) {
+	statement := tracing.SanitizeSQL(query)
+	fingerprint := Fingerprint(statement)
+
+	s.mu.Lock()
+	st, ok := s.statements[fingerprint]
+	if !ok {
+		if len(s.statements) >= maxStatements {
+			fingerprint, statement = otherStatement, otherStatement
+			st = s.statements[otherStatement]
+		}
+		if st == nil {
+			st = &statementStats{fingerprint: fingerprint, statement: statement}
+			s.statements[fingerprint] = st
+		}
+	}
+	st.calls++
+	st.rows += rows
+	if err != nil {
+		st.errors++
+	}
+	st.total += elapsed
+	st.max = max(st.max, elapsed)
+	if len(st.recent) < durationWindow {
+		st.recent = append(st.recent, elapsed)
+	} else {
+		st.recent[st.next] = elapsed
+		st.next = (st.next + 1) % durationWindow
+	}
+	s.mu.Unlock()
+
+	if threshold := s.slowThreshold(); threshold > 0 && elapsed >= threshold {
+		attrs := []interface{}{
+			"database_id", s.databaseID,
+			"fingerprint", fingerprint,
+			"statement", statement,
+			"args", ArgShapes(args),
+			"duration_ms", float64(elapsed.Microseconds()) / 1000,
+			"rows", rows,
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		logging.FromContext(ctx).Warn("slow query", attrs...)
+	}
+}
+
+// Statements returns a snapshot of every statement's timings.
+func (s *QueryStats) Statements() []StatementStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]StatementStats, 0, len(s.statements))
+	for _, st := range s.statements {
+		recent := append([]time.Duration(nil), st.recent...)
+		sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+		out = append(out, StatementStats{
+			Fingerprint: st.fingerprint,
+			Statement:   st.statement,
+			Calls:       st.calls,
+			Errors:      st.errors,
+			Rows:        st.rows,
+			Total:       st.total,
+			Mean:        st.total / time.Duration(st.calls),
+			P95:         percentile(recent, 0.95),
+			Max:         st.max,
+		})
+	}
+	return out
+}
+
+// TopStatements returns the n statements ranked highest by key.
+func TopStatements(stats []StatementStats, n int, key func(StatementStats) time.Duration) []StatementStats {
+	sorted := append([]StatementStats(nil), stats...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if key(sorted[i]) != key(sorted[j]) {
+			return key(sorted[i]) > key(sorted[j])
+		}
+		return sorted[i].Fingerprint < sorted[j].Fingerprint
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// percentile returns the p-th percentile of sorted durations (nearest rank)
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(float64(len(sorted))*p)) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// Fingerprint identifies a normalised statement.
+func Fingerprint(statement string) string {
+	sum := sha1.Sum([]byte(statement))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ArgShapes describes bind parameters by type, and length for strings, so
+// that a slow query can be reproduced in shape without logging its values.
+func ArgShapes(args []interface{}) []string {
+	shapes := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			shapes[i] = "nil"
+		case string:
+			shapes[i] = fmt.Sprintf("string(%d)", len(v))
+		case *string:
+			if v == nil {
+				shapes[i] = "*string(nil)"
+			} else {
+				shapes[i] = fmt.Sprintf("*string(%d)", len(*v))
+			}
+		case []byte:
+			shapes[i] = fmt.Sprintf("[]byte(%d)", len(v))
+		default:
+			shapes[i] = fmt.Sprintf("%T", v)
+		}
+	}
+	return shapes
+}
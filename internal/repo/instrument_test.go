@@ -0,0 +1,170 @@
+package repo_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// fakeConnector serves every query with a fixed set of transaction rows
+// after an optional delay, and every exec with one affected row
+type fakeConnector struct {
+	rows  int
+	delay time.Duration
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return &fakeConn{c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ c *fakeConnector }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	time.Sleep(c.c.delay)
+	return &fakeRows{left: c.c.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	time.Sleep(c.c.delay)
+	return driver.RowsAffected(1), nil
+}
+
+type fakeRows struct{ left int }
+
+func (r *fakeRows) Columns() []string {
+	return []string{"transactions_seq", "coin_symbol", "transaction_type", "quantity", "price_per_unit",
+		"total_cost", "transaction_date", "exchange", "notes", "created_at"}
+}
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.left == 0 {
+		return io.EOF
+	}
+	r.left--
+	copy(dest, []driver.Value{int64(r.left + 1), "BTC", "BUY", 0.5, 50000.0, 25000.0,
+		"2024-01-15T00:00:00", "Coinbase", nil, "2024-01-15T00:00:00"})
+	return nil
+}
+
+func newInstrumentedRepo(t *testing.T, connector *fakeConnector, threshold time.Duration) (*repo.Repository, *repo.QueryStats) {
+	t.Helper()
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(1) // a leaked connection would block the next statement
+	t.Cleanup(func() { db.Close() })
+	stats := repo.NewQueryStats("sales", func() time.Duration { return threshold })
+	return repo.New(repo.Instrument(db, stats), repo.Oracle), stats
+}
+
+// ============================================================================
+// Instrumentation Tests
+// ============================================================================
+
+func TestInstrument_RecordsStatements(t *testing.T) {
+	// Arrange
+	repository, stats := newInstrumentedRepo(t, &fakeConnector{rows: 3}, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Act: two pages and a lookup share the pool's single connection
+	for i := 0; i < 2; i++ {
+		if _, err := repository.ListTransactions(ctx, 1, 20); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := repository.GetTransaction(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repository.DeleteTransaction(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Assert
+	statements := stats.Statements()
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 distinct statements, got %+v", statements)
+	}
+
+	var list, get, del repo.StatementStats
+	for _, s := range statements {
+		if s.Fingerprint != repo.Fingerprint(s.Statement) {
+			t.Errorf("expected the fingerprint of %q, got %s", s.Statement, s.Fingerprint)
+		}
+		switch {
+		case strings.Contains(s.Statement, "ROWNUM"):
+			list = s
+		case strings.HasPrefix(s.Statement, "SELECT"):
+			get = s
+		case strings.HasPrefix(s.Statement, "DELETE"):
+			del = s
+		}
+	}
+	if list.Calls != 2 || list.Rows != 6 {
+		t.Errorf("expected the list query twice with 6 rows, got %+v", list)
+	}
+	if get.Calls != 1 || get.Rows != 1 {
+		t.Errorf("expected the lookup once with 1 row, got %+v", get)
+	}
+	if del.Calls != 1 || del.Rows != 1 {
+		t.Errorf("expected the delete once with 1 row affected, got %+v", del)
+	}
+	if strings.Contains(list.Statement, "YYYY") || strings.Contains(list.Statement, "\n") {
+		t.Errorf("expected a normalised statement, got %q", list.Statement)
+	}
+}
+
+func TestInstrument_SlowQueryLog(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	repository, stats := newInstrumentedRepo(t, &fakeConnector{rows: 1, delay: 20 * time.Millisecond}, 10*time.Millisecond)
+	notes := "private note"
+
+	// Act
+	err := repository.CreateTransaction(context.Background(), repo.Transaction{
+		CoinSymbol: "BTC", TransactionType: "BUY", TransactionDate: "2024-01-15",
+		Exchange: "Coinbase", Notes: &notes,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Assert: the statement and parameter shapes are logged, not the values
+	logs := buf.String()
+	for _, want := range []string{`"msg":"slow query"`, `"database_id":"sales"`, `INSERT INTO TRANSACTIONS`, `"string(3)"`, `"*string(12)"`} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("expected the slow query log to contain %s, got %s", want, logs)
+		}
+	}
+	if strings.Contains(logs, "private note") || strings.Contains(logs, "Coinbase") {
+		t.Errorf("slow query log contains bind values: %s", logs)
+	}
+
+	top := repo.TopStatements(stats.Statements(), 1, func(s repo.StatementStats) time.Duration { return s.P95 })
+	if len(top) != 1 || top[0].P95 < 20*time.Millisecond {
+		t.Errorf("expected the insert's p95 to reflect its delay, got %+v", top)
+	}
+}
+
+func TestArgShapes(t *testing.T) {
+	var nilNotes *string
+	got := repo.ArgShapes([]interface{}{"BTC", 42, 0.5, nil, nilNotes, []byte("ab")})
+	want := []string{"string(3)", "int", "float64", "nil", "*string(nil)", "[]byte(2)"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ReplicaLag reports how far the replica db is behind its primary.
+//
+// Oracle reads the apply lag of Active Data Guard; PostgreSQL the age of
+// the last replayed transaction, or 0 when everything received has been
+// replayed (an idle primary writes nothing to replay). SQLite has no
+// replication, so its replicas are copies that never lag.
+func ReplicaLag(ctx context.Context, db DBTX, d Dialect) (time.Duration, error) {
+	switch d.Name() {
+	case "oracle":
+		var lag sql.NullString
+		err := db.QueryRowContext(ctx,
+			`SELECT VALUE FROM V$DATAGUARD_STATS WHERE NAME = 'apply lag'`).Scan(&lag)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !lag.Valid) {
+			err = errors.New("apply lag unknown")
+		}
+		if err != nil {
+			return 0, fmt.Errorf("replica lag: %w", err)
+		}
+		return parseOracleInterval(lag.String)
+	case "postgres":
+		var seconds float64
+		err := db.QueryRowContext(ctx, `
+			SELECT CASE
+				WHEN NOT pg_is_in_recovery() THEN 0
+				WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+				ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+			END`).Scan(&seconds)
+		if err != nil {
+			return 0, fmt.Errorf("replica lag: %w", err)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	default:
+		return 0, nil
+	}
+}
+
+// oracleInterval matches a day to second interval as Data Guard reports
+// it, e.g. "+00 00:00:05"
+var oracleInterval = regexp.MustCompile(`^\+?(\d+) (\d{2}):(\d{2}):(\d{2})(\.\d+)?$`)
+
+// parseOracleInterval converts an Oracle day to second interval
+func parseOracleInterval(interval string) (time.Duration, error) {
+	m := oracleInterval.FindStringSubmatch(interval)
+	if m == nil {
+		return 0, fmt.Errorf("replica lag: unrecognised interval %q", interval)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		n, _ := strconv.Atoi(m[i+1])
+		d += time.Duration(n) * unit
+	}
+	if m[5] != "" {
+		fraction, _ := strconv.ParseFloat(m[5], 64)
+		d += time.Duration(fraction * float64(time.Second))
+	}
+	return d, nil
+}
@@ -0,0 +1,56 @@
+package repo_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hotbrandon/go-chi/internal/repo"
+)
+
+// ============================================================================
+// ReplicaLag Tests
+// ============================================================================
+
+func TestReplicaLag_Oracle(t *testing.T) {
+	tests := []struct {
+		applyLag string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{applyLag: "+00 00:00:05", want: 5 * time.Second},
+		{applyLag: "+01 02:03:04.500", want: 26*time.Hour + 3*time.Minute + 4500*time.Millisecond},
+		{applyLag: "5 seconds", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.applyLag, func(t *testing.T) {
+			// Arrange: V$DATAGUARD_STATS answers with the apply lag
+			db := sql.OpenDB(bannerConnector{tt.applyLag})
+			defer db.Close()
+
+			// Act
+			lag, err := repo.ReplicaLag(context.Background(), db, repo.Oracle12c)
+
+			// Assert
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", lag)
+				}
+				return
+			}
+			if err != nil || lag != tt.want {
+				t.Errorf("expected %v, got %v (%v)", tt.want, lag, err)
+			}
+		})
+	}
+}
+
+func TestReplicaLag_SQLite(t *testing.T) {
+	lag, err := repo.ReplicaLag(context.Background(), nil, repo.SQLite)
+
+	if err != nil || lag != 0 {
+		t.Errorf("expected SQLite copies not to lag, got %v (%v)", lag, err)
+	}
+}
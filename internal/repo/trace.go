@@ -0,0 +1,184 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hotbrandon/go-chi/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/hotbrandon/go-chi/internal/repo")
+
+// Rows changed by a statement; semconv only defines returned rows
+const affectedRowsKey = attribute.Key("db.response.affected_rows")
+
+// db.system.name of each dialect
+var dbSystems = map[string]attribute.KeyValue{
+	"oracle":   semconv.DBSystemNameOracleDB,
+	"postgres": semconv.DBSystemNamePostgreSQL,
+	"sqlite":   semconv.DBSystemNameSQLite,
+}
+
+// startStatement starts a client span for one statement
+func (r *Repository) startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	op := tracing.Operation(query)
+	return tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			dbSystems[r.dialect.Name()],
+			semconv.DBOperationName(op),
+			semconv.DBQueryText(tracing.SanitizeSQL(query)),
+		))
+}
+
+func endWithError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// conn takes a connection from the pool of db for one statement. Taking
+// it explicitly, rather than inside QueryContext, gives the wait for a free
+// connection its own span. In a transaction the statement runs on the
+// transaction's connection.
+func conn(ctx context.Context, db DBTX) (DBTX, func(), error) {
+	if instrumented, ok := db.(*instrumentedDB); ok {
+		return instrumented.conn(ctx)
+	}
+	pool, ok := db.(*sql.DB)
+	if !ok {
+		return db, func() {}, nil
+	}
+
+	ctx, span := tracer.Start(ctx, "db.pool.acquire", trace.WithSpanKind(trace.SpanKindInternal))
+	c, err := pool.Conn(ctx)
+	endWithError(span, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, func() { c.Close() }, nil
+}
+
+// execContext runs ExecContext in a span recording the rows affected. Like
+// queryContext, it takes the query with :1-style placeholders.
+func (r *Repository) execContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	query = r.dialect.Rebind(query)
+	ctx, span := r.startStatement(ctx, query)
+	defer func() { endWithError(span, err) }()
+
+	db, release, err := conn(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result, err = db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err == nil {
+		span.SetAttributes(affectedRowsKey.Int64(n))
+	}
+	return result, nil
+}
+
+// queryContext runs QueryContext on the reader in a span that lasts until
+// the rows are closed and records how many were read
+func (r *Repository) queryContext(ctx context.Context, query string, args ...interface{}) (*tracedRows, error) {
+	query = r.dialect.Rebind(query)
+	ctx, span := r.startStatement(ctx, query)
+	done := &rowsDone{}
+	ctx = context.WithValue(ctx, rowsDoneKey{}, done)
+
+	db, release, err := conn(ctx, r.reader)
+	if err != nil {
+		endWithError(span, err)
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		release()
+		endWithError(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span, release: release, done: done}, nil
+}
+
+// queryRowContext is queryContext for at most one row, like QueryRowContext
+func (r *Repository) queryRowContext(ctx context.Context, query string, args ...interface{}) *tracedRow {
+	rows, err := r.queryContext(ctx, query, args...)
+	return &tracedRow{rows: rows, err: err}
+}
+
+// tracedRows is a result set read inside a statement span
+type tracedRows struct {
+	*sql.Rows
+	span    trace.Span
+	release func()
+	done    *rowsDone
+	read    int
+	closed  bool
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		r.read++
+		return true
+	}
+	return false
+}
+
+// Close closes the rows, returns the connection and ends the span. The
+// connection is released after the rows, which hold it until closed.
+func (r *tracedRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	closeErr := r.Rows.Close()
+	r.release()
+
+	err := r.Rows.Err()
+	if err == nil {
+		err = closeErr
+	}
+	for _, f := range r.done.callbacks {
+		f(r.read, err)
+	}
+	r.span.SetAttributes(semconv.DBResponseReturnedRows(r.read))
+	endWithError(r.span, err)
+	return closeErr
+}
+
+// tracedRow mirrors sql.Row: errors surface from Scan
+type tracedRow struct {
+	rows *tracedRows
+	err  error
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	return r.rows.Close()
+}
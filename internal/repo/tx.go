@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// BeginTx starts a transaction at the given isolation level on the pool r
+// runs its statements on.
+//
+// go-ora only begins transactions at the default level, so Oracle sets
+// serializable with SET TRANSACTION. SQLite transactions are always
+// serializable.
+func (r *Repository) BeginTx(ctx context.Context, isolation sql.IsolationLevel) (*sql.Tx, error) {
+	db := r.db
+	if instrumented, ok := db.(*instrumentedDB); ok {
+		db = instrumented.db
+	}
+	pool, ok := db.(*sql.DB)
+	if !ok {
+		return nil, errors.New("begin transaction: already in a transaction")
+	}
+
+	switch r.dialect.Name() {
+	case "postgres":
+		return pool.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
+	case "oracle":
+		tx, err := pool.BeginTx(ctx, nil)
+		if err != nil || isolation != sql.LevelSerializable {
+			return tx, err
+		}
+		if _, err := tx.ExecContext(ctx, `SET TRANSACTION ISOLATION LEVEL SERIALIZABLE`); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("begin transaction: %w", err)
+		}
+		return tx, nil
+	default:
+		return pool.BeginTx(ctx, nil)
+	}
+}
+
+// IsSerializationFailure reports whether err means a transaction could not
+// be serialized with a concurrent one, and may succeed if run again.
+func IsSerializationFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001"
+	}
+	// go-ora and modernc.org/sqlite have no typed errors
+	message := err.Error()
+	return strings.Contains(message, "ORA-08177") || strings.Contains(message, "SQLITE_BUSY")
+}
+
+// Conflicted reports whether a statement run by a repository returned by
+// WithTx failed to serialize (see IsSerializationFailure). The transaction
+// must then be rolled back.
+func (r *Repository) Conflicted() bool {
+	return r.conflicted != nil && r.conflicted.Load()
+}
+
+// conflictObserver notes the serialization failures of the statements run
+// through it
+type conflictObserver struct {
+	DBTX
+	conflicted *atomic.Bool
+}
+
+func (o conflictObserver) observe(err error) {
+	if IsSerializationFailure(err) {
+		o.conflicted.Store(true)
+	}
+}
+
+func (o conflictObserver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := o.DBTX.ExecContext(ctx, query, args...)
+	o.observe(err)
+	return result, err
+}
+
+func (o conflictObserver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := o.DBTX.QueryContext(ctx, query, args...)
+	o.observe(err)
+	if err == nil {
+		onRowsDone(ctx, func(_ int, err error) { o.observe(err) })
+	}
+	return rows, err
+}
+
+func (o conflictObserver) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := o.DBTX.QueryRowContext(ctx, query, args...)
+	o.observe(row.Err())
+	return row
+}
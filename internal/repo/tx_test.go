@@ -0,0 +1,73 @@
+package repo_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/repo"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ============================================================================
+// Transaction Tests
+// ============================================================================
+
+func TestIsSerializationFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"oracle", errors.New("ORA-08177: can't serialize access for this transaction"), true},
+		{"postgres", fmt.Errorf("update: %w", &pgconn.PgError{Code: "40001"}), true},
+		{"postgres deadlock", &pgconn.PgError{Code: "40P01"}, false},
+		{"sqlite", errors.New("database is locked (5) (SQLITE_BUSY)"), true},
+		{"constraint", errors.New("ORA-02290: check constraint violated"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := repo.IsSerializationFailure(tt.err); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRepository_WithTx(t *testing.T) {
+	// Arrange
+	repository := newSQLiteRepository(t)
+	ctx := context.Background()
+	tx, err := repository.BeginTx(ctx, sql.LevelSerializable)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	txRepository := repository.WithTx(tx)
+
+	// Act: insert, then break a constraint, then roll back
+	if err := txRepository.CreateTransaction(ctx, repo.Transaction{CoinSymbol: "BTC", TransactionType: "B",
+		Quantity: 1, PricePerUnit: 100, TotalCost: 100, TransactionDate: "2024-01-15", Exchange: "BN"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	inTx, _ := txRepository.ListTransactions(ctx, 1, 10)
+	constraintErr := txRepository.CreateTransaction(ctx, repo.Transaction{CoinSymbol: "ETH", TransactionType: "X",
+		Quantity: 1, PricePerUnit: 1, TotalCost: 1, TransactionDate: "2024-01-15", Exchange: "BN"})
+	tx.Rollback()
+	after, _ := repository.ListTransactions(ctx, 1, 10)
+
+	// Assert
+	if len(inTx) != 1 {
+		t.Errorf("expected the insert to be visible in the transaction, got %+v", inTx)
+	}
+	if constraintErr == nil || txRepository.Conflicted() {
+		t.Errorf("expected a constraint error that is no conflict, got %v", constraintErr)
+	}
+	if len(after) != 0 {
+		t.Errorf("expected the rollback to discard the insert, got %+v", after)
+	}
+	if _, err := txRepository.BeginTx(ctx, sql.LevelReadCommitted); err == nil {
+		t.Error("expected a transaction not to begin inside another")
+	}
+}
@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// EncryptedFile reads secrets from a local file holding a JSON object of
+// name/value pairs sealed with AES-256-GCM. The file layout is the 12-byte
+// nonce followed by the ciphertext. The file is decrypted on every Fetch so
+// that replacing it rotates the secrets without a restart.
+type EncryptedFile struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewEncryptedFile returns a provider for the file at path. key must be 32
+// bytes.
+func NewEncryptedFile(path string, key []byte) (*EncryptedFile, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedFile{path: path, aead: aead}, nil
+}
+
+func (e *EncryptedFile) Fetch(ctx context.Context, name string) (string, error) {
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := e.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", errors.New("encrypted secrets file is truncated")
+	}
+	plaintext, err := e.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", errors.New("encrypted secrets file could not be decrypted (wrong key?)")
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return "", fmt.Errorf("encrypted secrets file: %w", err)
+	}
+
+	value, exists := values[name]
+	if !exists {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Seal encrypts values into the format read by EncryptedFile.
+func Seal(key []byte, values map[string]string) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secrets key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,26 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// FileProvider reads a secret from a file, as mounted by Docker secrets or
+// Kubernetes Secret volumes. The key is the file path. A single trailing
+// newline is stripped since most tools that write these files add one.
+type FileProvider struct{}
+
+func (FileProvider) Fetch(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
@@ -0,0 +1,100 @@
+// Package secrets resolves secret references such as database passwords
+// from pluggable backends: mounted files, a local encrypted file and a
+// Vault-compatible KV store.
+//
+// A reference has the form "<scheme>:<key>", for example
+//
+//	file:/run/secrets/sales_password
+//	encrypted:sales_password
+//	vault:secret/data/oracle/sales#password
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Provider fetches the current value of a secret by key. Implementations
+// must not cache values, so that a re-fetch picks up rotated secrets.
+type Provider interface {
+	Fetch(ctx context.Context, key string) (string, error)
+}
+
+// ErrNotFound is returned when a provider has no secret for the key.
+var ErrNotFound = errors.New("secret not found")
+
+// Resolver dispatches references to the provider registered for their scheme.
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver returns a Resolver with the file provider registered.
+func NewResolver() *Resolver {
+	r := &Resolver{providers: make(map[string]Provider)}
+	r.Register("file", FileProvider{})
+	return r
+}
+
+// Register adds or replaces the provider for scheme.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.providers[scheme] = p
+}
+
+// Resolve fetches the secret identified by ref.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	scheme, key, ok := strings.Cut(ref, ":")
+	if !ok || key == "" {
+		return "", fmt.Errorf("invalid secret reference %q (expected scheme:key)", ref)
+	}
+	p, exists := r.providers[scheme]
+	if !exists {
+		return "", fmt.Errorf("no secret provider registered for %q", scheme)
+	}
+	value, err := p.Fetch(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s secret: %w", scheme, err)
+	}
+	return value, nil
+}
+
+// FromEnv builds a Resolver from the environment (in os.Environ form):
+//
+//	SECRETS_FILE, SECRETS_KEY   enable the "encrypted" scheme; the key is
+//	                            base64-encoded AES-256
+//	VAULT_ADDR, VAULT_TOKEN     enable the "vault" scheme
+//
+// The "file" scheme is always available.
+func FromEnv(environ []string) (*Resolver, error) {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+
+	r := NewResolver()
+
+	if path := env["SECRETS_FILE"]; path != "" {
+		key, err := base64.StdEncoding.DecodeString(env["SECRETS_KEY"])
+		if err != nil {
+			return nil, fmt.Errorf("SECRETS_KEY: %w", err)
+		}
+		enc, err := NewEncryptedFile(path, key)
+		if err != nil {
+			return nil, err
+		}
+		r.Register("encrypted", enc)
+	}
+
+	if addr := env["VAULT_ADDR"]; addr != "" {
+		client := &http.Client{Timeout: 10 * time.Second}
+		r.Register("vault", NewVault(addr, env["VAULT_TOKEN"], client))
+	}
+
+	return r, nil
+}
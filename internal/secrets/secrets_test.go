@@ -0,0 +1,218 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hotbrandon/go-chi/internal/secrets"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// ============================================================================
+// FileProvider Tests
+// ============================================================================
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sales_password")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := secrets.NewResolver()
+
+	value, err := r.Resolve(context.Background(), "file:"+path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "s3cret" {
+		t.Errorf("expected trailing newline to be stripped, got %q", value)
+	}
+
+	_, err = r.Resolve(context.Background(), "file:"+path+".missing")
+	if !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// ============================================================================
+// EncryptedFile Tests
+// ============================================================================
+
+func TestEncryptedFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	writeSealed(t, path, map[string]string{"sales_password": "first"})
+
+	enc, err := secrets.NewEncryptedFile(path, testKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, err := enc.Fetch(context.Background(), "sales_password")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "first" {
+		t.Errorf("expected first, got %q", value)
+	}
+
+	// Replacing the file rotates the secret without a new provider
+	writeSealed(t, path, map[string]string{"sales_password": "second"})
+	value, _ = enc.Fetch(context.Background(), "sales_password")
+	if value != "second" {
+		t.Errorf("expected rotated value second, got %q", value)
+	}
+
+	if _, err := enc.Fetch(context.Background(), "hr_password"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestEncryptedFile_WrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	writeSealed(t, path, map[string]string{"sales_password": "first"})
+
+	enc, _ := secrets.NewEncryptedFile(path, []byte(strings.Repeat("x", 32)))
+	if _, err := enc.Fetch(context.Background(), "sales_password"); err == nil {
+		t.Error("expected decryption to fail with the wrong key")
+	}
+
+	if _, err := secrets.NewEncryptedFile(path, []byte("short")); err == nil {
+		t.Error("expected an error for a short key")
+	}
+}
+
+func writeSealed(t *testing.T, path string, values map[string]string) {
+	t.Helper()
+	data, err := secrets.Seal(testKey, values)
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ============================================================================
+// Vault Tests - against a local stand-in server
+// ============================================================================
+
+func newVaultStandIn(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/oracle/sales": // KV v2
+			json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{
+					"data":     map[string]any{"password": "kv2-pass", "user": "app_user"},
+					"metadata": map[string]any{"version": 3},
+				},
+			})
+		case "/v1/kv/oracle/hr": // KV v1
+			json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{"password": "kv1-pass"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"errors": []string{}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVault(t *testing.T) {
+	srv := newVaultStandIn(t, "root-token")
+	vault := secrets.NewVault(srv.URL, "root-token", srv.Client())
+
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{name: "kv v2 default field", key: "secret/data/oracle/sales", want: "kv2-pass"},
+		{name: "kv v2 explicit field", key: "secret/data/oracle/sales#user", want: "app_user"},
+		{name: "kv v1", key: "kv/oracle/hr#password", want: "kv1-pass"},
+		{name: "missing path", key: "secret/data/oracle/nope", wantErr: secrets.ErrNotFound},
+		{name: "missing field", key: "secret/data/oracle/sales#nope", wantErr: secrets.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := vault.Fetch(context.Background(), tt.key)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVault_BadToken(t *testing.T) {
+	srv := newVaultStandIn(t, "root-token")
+	vault := secrets.NewVault(srv.URL, "wrong", srv.Client())
+
+	if _, err := vault.Fetch(context.Background(), "secret/data/oracle/sales"); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+}
+
+// ============================================================================
+// Resolver Tests
+// ============================================================================
+
+func TestFromEnv(t *testing.T) {
+	srv := newVaultStandIn(t, "root-token")
+
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	writeSealed(t, path, map[string]string{"hr_password": "enc-pass"})
+
+	r, err := secrets.FromEnv([]string{
+		"VAULT_ADDR=" + srv.URL,
+		"VAULT_TOKEN=root-token",
+		"SECRETS_FILE=" + path,
+		"SECRETS_KEY=" + base64.StdEncoding.EncodeToString(testKey),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v, err := r.Resolve(context.Background(), "vault:secret/data/oracle/sales"); err != nil || v != "kv2-pass" {
+		t.Errorf("vault: expected kv2-pass, got %q (%v)", v, err)
+	}
+	if v, err := r.Resolve(context.Background(), "encrypted:hr_password"); err != nil || v != "enc-pass" {
+		t.Errorf("encrypted: expected enc-pass, got %q (%v)", v, err)
+	}
+}
+
+func TestResolver_InvalidReferences(t *testing.T) {
+	r := secrets.NewResolver()
+
+	for _, ref := range []string{"no-scheme", "file:", "vault:secret/data/x"} {
+		if _, err := r.Resolve(context.Background(), ref); err == nil {
+			t.Errorf("expected an error for %q", ref)
+		}
+	}
+}
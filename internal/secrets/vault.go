@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Vault reads secrets from a HashiCorp Vault compatible KV engine over HTTP.
+// Keys have the form "<path>#<field>", e.g. "secret/data/oracle/sales#password";
+// the field defaults to "password". Both KV v2 (data nested under data.data)
+// and KV v1 (data directly under data) responses are understood.
+type Vault struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewVault returns a Vault client for the server at addr.
+func NewVault(addr, token string, client *http.Client) *Vault {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Vault{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		client: client,
+	}
+}
+
+func (v *Vault) Fetch(ctx context.Context, key string) (string, error) {
+	path, field, _ := strings.Cut(key, "#")
+	if field == "" {
+		field = "password"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		v.addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		// Vault error bodies carry no secret material, but keep them short
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("vault returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+
+	data := payload.Data
+	if nested, ok := data["data"]; ok {
+		// KV v2 wraps the secret in data.data alongside metadata
+		var inner map[string]json.RawMessage
+		if err := json.Unmarshal(nested, &inner); err == nil {
+			data = inner
+		}
+	}
+
+	raw, exists := data[field]
+	if !exists {
+		return "", ErrNotFound
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("vault field %q is not a string", field)
+	}
+	return value, nil
+}
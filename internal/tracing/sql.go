@@ -0,0 +1,68 @@
+package tracing
+
+import "strings"
+
+// SanitizeSQL prepares a statement for a span: runs of whitespace become one
+// space, and string and number literals become "?", so that values inlined
+// into SQL never reach the trace backend. Bind variables (:1) are kept.
+func SanitizeSQL(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	var prev byte // last character written, ignoring whitespace
+	pendingSpace := false
+	write := func(c byte) {
+		if pendingSpace && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		pendingSpace = false
+		b.WriteByte(c)
+		prev = c
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pendingSpace = true
+		case c == '\'':
+			// Skip to the closing quote; '' is an escaped quote
+			for i++; i < len(query); i++ {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			write('?')
+		case isDigit(c) && !isIdentifier(prev) && prev != ':':
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			write('?')
+		default:
+			write(c)
+		}
+	}
+	return b.String()
+}
+
+// Operation returns the first keyword of a statement, such as "SELECT".
+func Operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentifier(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || isDigit(c) ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,84 @@
+// Package tracing sets up OpenTelemetry tracing: the exporter, W3C trace
+// context propagation, and a server span per HTTP request.
+//
+// Spans are created through the global TracerProvider, so packages such as
+// repo start child spans with otel.Tracer without depending on this one.
+// Until Setup installs an exporter the global provider is a no-op.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/hotbrandon/go-chi/internal/tracing"
+
+// Options configure Setup.
+type Options struct {
+	Exporter    string // "otlp", "stdout", or "" to export nothing
+	Endpoint    string // OTLP/HTTP host:port; empty uses the OTEL_* environment
+	Insecure    bool   // OTLP over plain HTTP
+	ServiceName string
+	SampleRatio float64 // of new traces; sampled parents are always followed
+}
+
+// Setup installs the global propagator and, when an exporter is configured,
+// a TracerProvider exporting to it. The returned function flushes pending
+// spans and must be called before exit.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		var clientOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, clientOpts...)
+	case "stdout":
+		// The console exporter, but on stderr: stdout carries the JSON logs
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(opts.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
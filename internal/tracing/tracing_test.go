@@ -0,0 +1,132 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/hotbrandon/go-chi/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a provider that keeps finished spans in memory
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+// ============================================================================
+// Middleware Tests
+// ============================================================================
+
+func TestMiddleware_ServerSpan(t *testing.T) {
+	// Arrange
+	recorder := recordSpans(t)
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(tracing.Middleware)
+	r.Route("/api/{database_id}", func(r chi.Router) {
+		r.Get("/crypto/transactions/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/sales/crypto/transactions/7", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if want := "GET /api/{database_id}/crypto/transactions/{id}"; span.Name() != want {
+		t.Errorf("expected span name %q, got %q", want, span.Name())
+	}
+	if span.SpanContext().TraceID().String() != traceID {
+		t.Errorf("expected the incoming trace to continue, got trace %s", span.SpanContext().TraceID())
+	}
+	if !span.Parent().IsRemote() {
+		t.Error("expected the span's parent to be the remote caller")
+	}
+
+	attrs := attributes(span)
+	if got := attrs["http.route"].AsString(); got != "/api/{database_id}/crypto/transactions/{id}" {
+		t.Errorf("unexpected http.route %q", got)
+	}
+	if got := attrs[tracing.DatabaseIDKey].AsString(); got != "sales" {
+		t.Errorf("expected database id sales, got %q", got)
+	}
+	if attrs[tracing.RequestIDKey].AsString() == "" {
+		t.Error("expected the request id on the span")
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", got)
+	}
+	if span.Status().Code != codes.Error {
+		t.Error("expected a 5xx response to mark the span as failed")
+	}
+}
+
+// ============================================================================
+// SQL Tests
+// ============================================================================
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "SELECT *\n\t\tFROM transactions\n\t\tWHERE transactions_seq = :1",
+			want:  "SELECT * FROM transactions WHERE transactions_seq = :1",
+		},
+		{
+			query: "SELECT TO_CHAR(d, 'YYYY-MM-DD\"T\"HH24:MI:SS') FROM t WHERE n = 'O''Brien'",
+			want:  "SELECT TO_CHAR(d, ?) FROM t WHERE n = ?",
+		},
+		{
+			query: "SELECT col2 FROM t WHERE qty > 10.5 AND ROWNUM <= :10",
+			want:  "SELECT col2 FROM t WHERE qty > ? AND ROWNUM <= :10",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tracing.SanitizeSQL(tt.query); got != tt.want {
+			t.Errorf("SanitizeSQL(%q)\n got %q\nwant %q", tt.query, got, tt.want)
+		}
+	}
+
+	if got := tracing.Operation("\n\t\tinsert INTO t VALUES (:1)"); got != "INSERT" {
+		t.Errorf("expected INSERT, got %q", got)
+	}
+}